@@ -0,0 +1,147 @@
+package main
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"log/slog"
+	"maps"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	"github.com/donovandicks/llm-government/internal"
+)
+
+// readWorldSnapshot reads a world snapshot, or the world of a simulation
+// checkpoint.
+func readWorldSnapshot(filePath string) (*internal.WorldSnapshot, error) {
+	data, err := os.ReadFile(filePath)
+	if err != nil {
+		return nil, err
+	}
+
+	var cp internal.Checkpoint
+	if err := json.Unmarshal(data, &cp); err != nil {
+		return nil, fmt.Errorf("invalid snapshot %s: %w", filePath, err)
+	}
+	if cp.World != nil {
+		return cp.World, nil
+	}
+
+	var snapshot internal.WorldSnapshot
+	if err := json.Unmarshal(data, &snapshot); err != nil {
+		return nil, fmt.Errorf("invalid snapshot %s: %w", filePath, err)
+	}
+	return &snapshot, nil
+}
+
+// renderWorld writes the state of the world in a snapshot, listing at most
+// limit entities of each archetype, or all if limit is negative.
+func renderWorld(w io.Writer, snapshot *internal.WorldSnapshot, limit int) error {
+	tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+
+	entities := 0
+	for _, arch := range snapshot.Archetypes {
+		entities += len(arch.Entities)
+	}
+
+	fmt.Fprintf(tw, "Version\t%d\n", snapshot.Version)
+	fmt.Fprintf(tw, "Tick\t%d\n", snapshot.Tick)
+	fmt.Fprintf(tw, "Time\t%s (%s simulated)\n", internal.WorldEpoch.Add(snapshot.Clock).Format(time.RFC3339), snapshot.Clock)
+	fmt.Fprintf(tw, "Entities\t%d\n", entities)
+
+	fmt.Fprintln(tw, "\nInputs")
+	for _, name := range slices.Sorted(maps.Keys(snapshot.Inputs)) {
+		fmt.Fprintf(tw, "  %s\t%v\n", name, snapshot.Inputs[name])
+	}
+
+	fmt.Fprintln(tw, "\nComponents")
+	for _, name := range slices.Sorted(maps.Keys(snapshot.Components)) {
+		fmt.Fprintf(tw, "  %s\tv%d\n", name, snapshot.Components[name])
+	}
+	if err := tw.Flush(); err != nil {
+		return err
+	}
+
+	for _, arch := range snapshot.Archetypes {
+		fmt.Fprintf(w, "\nArchetype [%s]: %d entities\n", strings.Join(arch.Components, ","), len(arch.Entities))
+		if limit == 0 {
+			continue
+		}
+
+		columns := make(map[string][]json.RawMessage, len(arch.Components))
+		for _, name := range arch.Components {
+			var values []json.RawMessage
+			if err := json.Unmarshal(arch.Data[name], &values); err != nil {
+				return fmt.Errorf("invalid data of component %q: %w", name, err)
+			}
+			columns[name] = values
+		}
+
+		tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+		fmt.Fprintf(tw, "  entity\t%s\n", strings.Join(arch.Components, "\t"))
+		for row, entity := range arch.Entities {
+			if limit > 0 && row >= limit {
+				fmt.Fprintf(tw, "  ...\t%d more\n", len(arch.Entities)-row)
+				break
+			}
+
+			fmt.Fprintf(tw, "  %d", entity)
+			for _, name := range arch.Components {
+				value := "-"
+				if row < len(columns[name]) {
+					value = string(columns[name][row])
+				}
+				fmt.Fprintf(tw, "\t%s", value)
+			}
+			fmt.Fprintln(tw)
+		}
+		if err := tw.Flush(); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// inspectCommand prints the world state of a snapshot, checkpoint or the
+// final state of a run.
+func inspectCommand(ctx context.Context, args []string) int {
+	fs := newFlagSet("inspect", "<snapshot | checkpoint | run directory>")
+	limit := fs.Int("entities", 10, "The number of entities listed per archetype, all if negative")
+	asJSON := fs.Bool("json", false, "Print the world snapshot as JSON instead")
+	if code, ok := parseFlags(fs, args, 1); !ok {
+		return code
+	}
+
+	filePath := fs.Arg(0)
+	if info, err := os.Stat(filePath); err == nil && info.IsDir() {
+		filePath = filepath.Join(filePath, "final.json")
+	}
+
+	snapshot, err := readWorldSnapshot(filePath)
+	if err != nil {
+		slog.Error("failed to read snapshot", "error", err)
+		return exitFailure
+	}
+
+	if *asJSON {
+		out, _ := json.MarshalIndent(snapshot, "", "  ")
+		fmt.Println(string(out))
+		return exitOK
+	}
+
+	w := bufio.NewWriter(os.Stdout)
+	defer w.Flush()
+
+	if err := renderWorld(w, snapshot, *limit); err != nil {
+		slog.Error("failed to render snapshot", "error", err)
+		return exitFailure
+	}
+	return exitOK
+}
@@ -3,129 +3,321 @@ package internal
 import (
 	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"log/slog"
 	"time"
 
-	"github.com/google/uuid"
 	_ "github.com/joho/godotenv/autoload"
-	"github.com/openai/openai-go"
-	"github.com/openai/openai-go/responses"
-	"github.com/openai/openai-go/shared"
 	"go.opentelemetry.io/otel/attribute"
 	"go.opentelemetry.io/otel/trace"
 )
 
 type Agent struct {
 	ID         string
+	Name       string
 	logger     *slog.Logger
 	simulation Simulation
 
-	client       openai.Client
-	model        string
+	llm          LLMClient
+	reasoning    ReasoningEffort
 	systemPrompt string
 
-	bus MessageBus
+	rng        *RNG
+	bus        MessageBus
+	memory     *Memory
+	summarizer Summarizer
+
+	replyAttempts int
+	toolSteps     int
+	replies       int // The number of replies generated so far
+}
+
+type AgentOptions struct {
+	ID            string      // Identifies the agent, drawn from RNG if empty. Set it to continue an agent from a checkpoint
+	RNG           *RNG        // The agent's random stream, used for its ID and message IDs. Randomly seeded if nil
+	Config        AgentConfig // Persona and reasoning effort of the agent, anonymous with medium effort if unset
+	Memory        MemoryOptions
+	Summarizer    Summarizer // Compresses older memory, defaults to summarizing with the agent's LLM
+	ReplyAttempts int        // Attempts at generating a reply matching the schema, defaults to 3
+	ToolSteps     int        // Rounds of tool calls allowed before the agent must reply, defaults to 3
 }
 
-func NewAgent(ctx context.Context, sim Simulation, bus MessageBus) *Agent {
-	agentId := uuid.NewString()
-	logger := slog.With("agentId", agentId)
+func NewAgent(ctx context.Context, sim Simulation, bus MessageBus, llm LLMClient, opts AgentOptions) *Agent {
+	rng := opts.RNG
+	if rng == nil {
+		rng = randomRNG()
+	}
+
+	agentId := opts.ID
+	if agentId == "" {
+		agentId = rng.UUID()
+	}
+	logger := slog.With("agentId", agentId, "agentName", opts.Config.Name)
 
 	bus.Subscribe(ctx, agentId)
 
-	systemPrompt := new(PromptBuilder).
+	components, _ := json.Marshal(CompReg.Components())
+
+	prompt := new(PromptBuilder).
 		WithRole("You are acting in a simulation with other agents.").
 		WithIntroducer("Here is the scenario.").
 		WithParagraph(sim.Scenario).
-		// TODO: Incorporate other simulation details
+		WithIntroducer("Each person in the world state combines the fields of these components:").
+		WithCode(string(components), "json")
+
+	if opts.Config.Name != "" {
+		prompt.
+			WithIntroducer(fmt.Sprintf("You are %s. Here is your persona.", opts.Config.Name)).
+			WithPersona(opts.Config).
+			WithSystemMessage("Stay in character: let your persona shape what you say, how you say it and how you vote.")
+	}
+
+	systemPrompt := prompt.
 		WithSystemMessage("Read messages from other participants and respond accordingly.").
+		WithSystemMessage("You may call tools to set the world's policy inputs directly before replying.").
 		Build()
 
+	reasoning := opts.Config.Reasoning
+	if reasoning == "" {
+		reasoning = ReasoningEffortMedium
+	}
+
+	summarizer := opts.Summarizer
+	if summarizer == nil {
+		summarizer = NewLLMSummarizer(llm)
+	}
+
+	replyAttempts := opts.ReplyAttempts
+	if replyAttempts <= 0 {
+		replyAttempts = 3
+	}
+
+	toolSteps := opts.ToolSteps
+	if toolSteps <= 0 {
+		toolSteps = 3
+	}
+
 	return &Agent{
 		ID:           agentId,
+		Name:         opts.Config.Name,
 		logger:       logger,
 		simulation:   sim,
-		client:       openai.NewClient(),
-		model:        "gpt-5",
+		llm:          llm,
+		reasoning:    reasoning,
 		systemPrompt: systemPrompt,
+		rng:          rng,
 		bus:          bus,
+		memory:       NewMemory(opts.Memory),
+		summarizer:   summarizer,
+
+		replyAttempts: replyAttempts,
+		toolSteps:     toolSteps,
+	}
+}
+
+// label identifies the agent in prompts by name and ID.
+func (a *Agent) label() string {
+	if a.Name == "" {
+		return a.ID
+	}
+	return fmt.Sprintf("%s (%s)", a.ID, a.Name)
+}
+
+// historyItems renders remembered entries as model input, attributing
+// the agent's own replies to the assistant.
+func historyItems(entries []MemoryEntry) []InputItem {
+	items := make([]InputItem, 0, len(entries))
+	for _, entry := range entries {
+		switch entry.Kind {
+		case MemoryReceived:
+			items = append(items, InputItem{
+				Role: RoleUser,
+				Content: new(PromptBuilder).
+					WithMessage(*entry.Message).
+					Build(),
+			})
+		case MemoryReply:
+			items = append(items, InputItem{Role: RoleAssistant, Content: entry.Content})
+		case MemoryObservation:
+			items = append(items, InputItem{
+				Role: RoleSystem,
+				Content: new(PromptBuilder).
+					WithIntroducer(fmt.Sprintf("A summary of the world state at tick %d was:", entry.Tick)).
+					WithCode(entry.Content, "json").
+					Build(),
+			})
+		}
 	}
+	return items
 }
 
-func (a *Agent) readInbox(ctx context.Context, inbox []Message, obs *Observation) (string, error) {
-	// TODO: Keep track of conversation history in agent context
+// callTool applies the action requested by a tool call to the world and
+// returns the result reported back to the model.
+func (a *Agent) callTool(ctx context.Context, w *World, call ToolCall) string {
+	action, err := w.ActionFromToolCall(call)
+	if err == nil {
+		err = w.ApplyAction(action)
+	}
+	if err != nil {
+		a.logger.Warn("tool call failed", "tool", call.Name, "error", err)
+		return fmt.Sprintf("error: %s", err)
+	}
+
+	a.logger.Info("applied action", "input", action.InputName, "value", action.Value)
+	return fmt.Sprintf("ok: %s is now %v", action.InputName, action.Value)
+}
+
+func (a *Agent) readInbox(ctx context.Context, w *World, inbox []Message, obs *Observation) (*AgentReply, error) {
+	stats, err := a.memory.Compact(ctx, a.summarizer)
+	if errors.Is(err, ErrCassetteMismatch) {
+		return nil, err
+	} else if err != nil {
+		a.logger.Warn("failed to summarize memory", "error", err)
+	}
+	trace.SpanFromContext(ctx).SetAttributes(
+		attribute.Int("memory.compressedEntries", stats.Entries),
+		attribute.Int("memory.compressedTokens", stats.Tokens),
+		attribute.Int("memory.summaryTokens", stats.SummaryTokens),
+	)
+
+	history := a.memory.Recall()
 
 	taskPrompt := new(PromptBuilder).
 		WithTask(
 			"You have received at least one new message. Read it/them and generate a reply.",
+			WithOutputFormat("Reply with JSON matching the agent_reply schema. Vote on open proposals by their id."),
 			WithItems(
-				fmt.Sprintf("You are agent %s", a.ID),
-				fmt.Sprintf("The current time is %s", time.Now().Format(time.RFC3339)),
+				fmt.Sprintf("You are agent %s", a.label()),
+				fmt.Sprintf("The current time is %s", w.Now().Format(time.RFC3339)),
 			),
 		).
 		Build()
 
-	messages := []string{}
-	for _, msg := range inbox {
-		prompt := new(PromptBuilder).
-			WithItems(
-				fmt.Sprintf("This is message %s", msg.Metadata.ID),
-				fmt.Sprintf("This message is from %s", msg.Metadata.Sender),
-				fmt.Sprintf("This message was sent at %s", msg.Metadata.SentAt),
-			).
-			Build()
-		messages = append(messages, prompt)
-	}
-
 	inboxPrompt := new(PromptBuilder).
 		WithIntroducer("Here is your inbox:").
-		WithItems(messages...).
+		WithInbox(inbox).
 		Build()
 
-	bs, _ := json.Marshal(obs)
+	observation := obs.ToJSON()
 	worldPrompt := new(PromptBuilder).
 		WithIntroducer("Here is the world state:").
-		WithCode(string(bs), "json").
+		WithCode(observation, "json").
 		Build()
 
-	params := responses.ResponseNewParams{
-		Model:        a.model,
-		Instructions: openai.String(a.systemPrompt),
-		Input: responses.ResponseNewParamsInputUnion{
-			OfInputItemList: responses.ResponseInputParam{
-				{OfMessage: &responses.EasyInputMessageParam{
-					Content: responses.EasyInputMessageContentUnionParam{OfString: openai.String(taskPrompt)},
-					Role:    "system",
-				}},
-				{OfMessage: &responses.EasyInputMessageParam{
-					Content: responses.EasyInputMessageContentUnionParam{OfString: openai.String(worldPrompt)},
-					Role:    "system",
-				}},
-				{OfMessage: &responses.EasyInputMessageParam{
-					Content: responses.EasyInputMessageContentUnionParam{OfString: openai.String(inboxPrompt)},
-					Role:    "assistant",
-				}},
+	input := []InputItem{}
+	if summary := a.memory.Summary(); summary != "" {
+		input = append(input, InputItem{
+			Role: RoleSystem,
+			Content: new(PromptBuilder).
+				WithIntroducer("Here is a summary of what happened earlier:").
+				WithSummary(summary).
+				Build(),
+		})
+	}
+	input = append(input, historyItems(history)...)
+	input = append(input,
+		InputItem{Role: RoleSystem, Content: taskPrompt},
+		InputItem{Role: RoleSystem, Content: worldPrompt},
+		// Messages of other participants are user input, as they are once
+		// remembered, so the request never ends on an assistant prefill
+		InputItem{Role: RoleUser, Content: inboxPrompt},
+	)
+
+	req := GenerateRequest{
+		SystemPrompt: a.systemPrompt,
+		Input:        input,
+		Reasoning:    a.reasoning,
+		Schema:       &AgentReplySchema,
+		Tools:        w.Tools(),
+	}
+	a.replies++
+	req.Reply = a.replies
+
+	var (
+		reply     *AgentReply
+		text      string
+		usage     Usage
+		toolCalls int
+	)
+	for attempt, step := 1, 1; ; step++ {
+		// Stop offering tools once the agent has used up its steps so
+		// that it has to reply.
+		if step > a.toolSteps {
+			req.Tools = nil
+		}
+
+		response, err := a.llm.Generate(ctx, req)
+		if err != nil {
+			return nil, err
+		}
+
+		text = response.Text
+		usage.InputTokens += response.Usage.InputTokens
+		usage.OutputTokens += response.Usage.OutputTokens
+		usage.TotalTokens += response.Usage.TotalTokens
+
+		if len(response.ToolCalls) > 0 && req.Tools != nil {
+			req.Input = append(req.Input, InputItem{Role: RoleAssistant, Content: text, ToolCalls: response.ToolCalls})
+			for _, call := range response.ToolCalls {
+				req.Input = append(req.Input, InputItem{Role: RoleTool, ToolCallID: call.ID, Content: a.callTool(ctx, w, call)})
+			}
+			toolCalls += len(response.ToolCalls)
+			continue
+		}
+
+		reply, err = ParseAgentReply(text)
+		if err == nil {
+			break
+		}
+		if attempt >= a.replyAttempts {
+			return nil, fmt.Errorf("reply did not match schema after %d attempts: %w", attempt, err)
+		}
+
+		a.logger.Warn("reply did not match schema, retrying", "attempt", attempt, "error", err)
+		attempt++
+		req.Input = append(req.Input,
+			InputItem{Role: RoleAssistant, Content: text},
+			InputItem{Role: RoleSystem, Content: new(PromptBuilder).
+				WithSystemMessage(fmt.Sprintf("Your reply was invalid: %s. Reply again following the schema.", err)).
+				Build(),
 			},
-		},
-		Reasoning: shared.ReasoningParam{Effort: shared.ReasoningEffortMedium},
+		)
 	}
 
-	response, err := a.client.Responses.New(ctx, params)
-	if err != nil {
-		return "", err
+	if a.memory.LastObservedTick() != obs.Tick {
+		// Only a summary is remembered, the full state is sent every cycle
+		a.memory.Remember(MemoryEntry{Kind: MemoryObservation, Tick: obs.Tick, Content: obs.SummaryJSON()})
 	}
+	for _, msg := range inbox {
+		a.memory.Remember(MemoryEntry{Kind: MemoryReceived, Tick: obs.Tick, Message: &msg})
+	}
+	a.memory.Remember(MemoryEntry{Kind: MemoryReply, Tick: obs.Tick, Content: text})
 
-	text := response.OutputText()
-	trace.SpanFromContext(ctx).SetAttributes(attribute.String("response", text))
+	trace.SpanFromContext(ctx).SetAttributes(
+		attribute.String("response", text),
+		attribute.Int("historySize", len(history)),
+		attribute.Int("proposals", len(reply.Proposals)),
+		attribute.Int("votes", len(reply.Votes)),
+		attribute.Int("actions", len(reply.Actions)),
+		attribute.Int("toolCalls", toolCalls),
+		attribute.Int64("usage.inputTokens", usage.InputTokens),
+		attribute.Int64("usage.outputTokens", usage.OutputTokens),
+	)
 
-	return text, nil
+	return reply, nil
 }
 
-func (a *Agent) Run(ctx context.Context, obs *Observation) {
+// Run replies to any messages in the agent's inbox, returning the reply
+// or nil if the agent had nothing to reply to.
+//
+// Failing to reply is logged and skips the agent's turn, except for errors
+// that invalidate the rest of the run, such as [ErrCassetteMismatch], which
+// are returned.
+func (a *Agent) Run(ctx context.Context, w *World, obs *Observation) (*AgentReply, error) {
 	ctx, span := Tracer.Start(ctx, "run agent", trace.WithAttributes(
 		attribute.String("simulation", a.simulation.ID()),
-		attribute.String("model", a.model),
+		attribute.String("model", a.llm.Model()),
 		attribute.String("agent", a.ID),
 		attribute.String("observation", obs.ToJSON()),
 	))
@@ -134,16 +326,18 @@ func (a *Agent) Run(ctx context.Context, obs *Observation) {
 	msgs := a.bus.Drain(ctx, a.ID)
 	span.SetAttributes(attribute.Int("inboxSize", len(msgs)))
 	if len(msgs) == 0 {
-		return
+		return nil, nil
 	}
 
-	reply, err := a.readInbox(ctx, msgs, obs)
-	if err != nil {
+	reply, err := a.readInbox(ctx, w, msgs, obs)
+	if errors.Is(err, ErrCassetteMismatch) {
+		return nil, err
+	} else if err != nil {
 		a.logger.Error("failed to generate a reply to message", "error", err)
-		return
+		return nil, nil
 	}
 
-	a.bus.Publish(ctx, a.ID, reply)
+	a.bus.Publish(ctx, NewReplyMessage(a.rng, w.Now(), a.ID, reply).InReplyTo(msgs[len(msgs)-1]))
 
-	// Take Action?
+	return reply, nil
 }
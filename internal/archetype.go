@@ -1,7 +1,6 @@
 package internal
 
 import (
-	"fmt"
 	"reflect"
 	"slices"
 	"strings"
@@ -11,14 +10,17 @@ import (
 // group of component types.
 type ArchetypeSignature []ComponentID
 
+// String returns the key of the signature: the registered names of its
+// components in sorted order, e.g. "[identity,mood,stats]". Keys are
+// stable across runs and never collide, while unregistered components
+// are listed by their process-local ID.
 func (as ArchetypeSignature) String() string {
-	var sb strings.Builder
-	sb.WriteRune('[')
+	keys := make([]string, 0, len(as))
 	for _, id := range as {
-		sb.WriteString(fmt.Sprintf("%v", id))
+		keys = append(keys, CompReg.key(id))
 	}
-	sb.WriteRune(']')
-	return sb.String()
+	slices.Sort(keys)
+	return "[" + strings.Join(keys, ",") + "]"
 }
 
 func MakeSignature(components ...Component) ArchetypeSignature {
@@ -94,3 +96,62 @@ func (a *Archetype) HasComponents(query ArchetypeSignature) bool {
 	// If we got through the entire query, it has all of the requisite components
 	return queryIdx == len(query)
 }
+
+// EntityComponents returns a copy of each component of the entity in
+// signature order.
+func (a *Archetype) EntityComponents(entity EntityID) []Component {
+	idx, ok := a.EntityMap[entity]
+	if !ok {
+		return nil
+	}
+
+	components := make([]Component, 0, len(a.Signature))
+	for _, id := range a.Signature {
+		components = append(components, reflect.ValueOf(a.Components[id]).Index(idx).Interface())
+	}
+	return components
+}
+
+// SetComponent overwrites the entity's component of the same type as c.
+func (a *Archetype) SetComponent(entity EntityID, c Component) bool {
+	idx, ok := a.EntityMap[entity]
+	if !ok {
+		return false
+	}
+
+	id := CompReg.GetComponentID(c)
+	slice, ok := a.Components[id]
+	if !ok {
+		return false
+	}
+
+	reflect.ValueOf(slice).Index(idx).Set(reflect.ValueOf(c))
+	return true
+}
+
+// RemoveEntity drops the entity from the archetype by swapping the last
+// entity into its place, keeping the component slices dense.
+func (a *Archetype) RemoveEntity(entity EntityID) bool {
+	idx, ok := a.EntityMap[entity]
+	if !ok {
+		return false
+	}
+
+	last := len(a.Entities) - 1
+	for id, slice := range a.Components {
+		sliceVal := reflect.ValueOf(slice)
+		if idx != last {
+			sliceVal.Index(idx).Set(sliceVal.Index(last))
+		}
+		sliceVal.Index(last).SetZero() // Release references held by the removed component
+		a.Components[id] = sliceVal.Slice(0, last).Interface()
+	}
+
+	moved := a.Entities[last]
+	a.Entities[idx] = moved
+	a.EntityMap[moved] = idx
+	a.Entities = a.Entities[:last]
+	delete(a.EntityMap, entity)
+
+	return true
+}
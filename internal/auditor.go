@@ -1,6 +1,8 @@
 package internal
 
 import (
+	"bufio"
+	"encoding/json"
 	"fmt"
 	"log/slog"
 	"os"
@@ -9,31 +11,67 @@ import (
 // TODO: Parameterize audit backend, e.g. FileSystem vs. Database vs. etc.
 type Auditor struct {
 	AuditLog chan Message
+
+	file *os.File
+	done chan struct{}
 }
 
-func NewAuditor() *Auditor {
+// NewAuditor creates the audit log file, replacing any previous one. Every
+// message is written to it as a line of JSON, see [ReadAuditLog].
+func NewAuditor(filePath string) (*Auditor, error) {
+	f, err := os.Create(filePath)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create audit log: %w", err)
+	}
+
 	return &Auditor{
 		AuditLog: make(chan Message),
-	}
+		file:     f,
+		done:     make(chan struct{}),
+	}, nil
 }
 
+// Stop closes the audit log and waits for the messages already sent to it
+// to be written. Nothing may be sent to the audit log afterwards.
 func (a *Auditor) Stop() {
 	close(a.AuditLog)
+	<-a.done
 }
 
 func (a *Auditor) Run() {
 	slog.Info("starting auditor")
-	f, err := os.OpenFile("/app/audit.log", os.O_APPEND|os.O_WRONLY|os.O_CREATE, 0o644)
+	defer close(a.done)
+	defer a.file.Close()
+
+	// TODO: Buffer these writes if they become a bottleneck, but they should probably be okay
+	// since they are run in a separate goroutine and the rest of the app is bound by LLM latency
+	encoder := json.NewEncoder(a.file)
+	for msg := range a.AuditLog {
+		slog.Debug("auditing message", "sender", msg.Metadata.Sender, "contents", msg.Contents)
+		if err := encoder.Encode(msg); err != nil {
+			slog.Error("failed to write audit log", "error", err)
+		}
+	}
+}
+
+// ReadAuditLog reads the messages written to an audit log, in the order
+// they were sent.
+func ReadAuditLog(filePath string) ([]Message, error) {
+	f, err := os.Open(filePath)
 	if err != nil {
-		slog.Error("failed to open audit file", "error", err)
-		panic(err)
+		return nil, err
 	}
 	defer f.Close()
 
-	for msg := range a.AuditLog {
-		slog.Debug("auditing message", "sender", msg.Metadata.Sender, "contents", msg.Contents)
-		// TODO: Buffer these writes if they become a bottleneck, but they should probably be okay
-		// since they are run in a separate goroutine and the rest of the app is bound by LLM latency
-		fmt.Fprintf(f, "[%s] %s - %s\n", msg.Metadata.SentAt, msg.Metadata.Sender, msg.Contents)
+	var msgs []Message
+	scanner := bufio.NewScanner(f)
+	scanner.Buffer(nil, 16*1024*1024) // Messages carry whole agent replies
+	for line := 1; scanner.Scan(); line++ {
+		var msg Message
+		if err := json.Unmarshal(scanner.Bytes(), &msg); err != nil {
+			return nil, fmt.Errorf("%s:%d: invalid message: %w", filePath, line, err)
+		}
+		msgs = append(msgs, msg)
 	}
+	return msgs, scanner.Err()
 }
@@ -0,0 +1,176 @@
+package internal
+
+import (
+	"bufio"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"regexp"
+	"sync"
+)
+
+var ErrCassetteMismatch = errors.New("no recorded response matches request")
+
+// CassetteEntry is a single recorded request/response pair. Cassettes are
+// stored as JSON lines with one entry per line.
+type CassetteEntry struct {
+	Hash     string           `json:"hash"`
+	Model    string           `json:"model"`
+	Request  GenerateRequest  `json:"request"`
+	Response GenerateResponse `json:"response"`
+}
+
+var (
+	uuidPattern      = regexp.MustCompile(`[0-9a-fA-F]{8}-[0-9a-fA-F]{4}-[0-9a-fA-F]{4}-[0-9a-fA-F]{4}-[0-9a-fA-F]{12}`)
+	timestampPattern = regexp.MustCompile(`\d{4}-\d{2}-\d{2}T\d{2}:\d{2}:\d{2}(\.\d+)?(Z|[+-]\d{2}:\d{2})`)
+)
+
+// HashRequest returns a stable hash of the prompt inputs of a request.
+//
+// IDs and timestamps are normalized before hashing, so a run replays even
+// if they are drawn differently from the recording, e.g. without a seed.
+// Requests differing only in them share a hash and are served in the order
+// they were recorded.
+func HashRequest(req GenerateRequest) string {
+	bs, _ := json.Marshal(req)
+	bs = uuidPattern.ReplaceAll(bs, []byte("<id>"))
+	bs = timestampPattern.ReplaceAll(bs, []byte("<time>"))
+	sum := sha256.Sum256(bs)
+	return hex.EncodeToString(sum[:])
+}
+
+// CassetteRecorder appends every request made through its clients, along
+// with the response, to a cassette file. The file is replaced, so a
+// cassette only ever holds a single run.
+type CassetteRecorder struct {
+	sync.Mutex
+
+	file *os.File
+	enc  *json.Encoder
+}
+
+func NewCassetteRecorder(filePath string) (*CassetteRecorder, error) {
+	f, err := os.OpenFile(filePath, os.O_WRONLY|os.O_CREATE|os.O_TRUNC, 0o644)
+	if err != nil {
+		return nil, fmt.Errorf("cannot open cassette: %w", err)
+	}
+
+	return &CassetteRecorder{file: f, enc: json.NewEncoder(f)}, nil
+}
+
+func (r *CassetteRecorder) Close() error {
+	return r.file.Close()
+}
+
+func (r *CassetteRecorder) record(entry CassetteEntry) error {
+	r.Lock()
+	defer r.Unlock()
+
+	return r.enc.Encode(entry)
+}
+
+// Wrap returns an [LLMClient] that records every call made to client.
+func (r *CassetteRecorder) Wrap(client LLMClient) LLMClient {
+	return &recordingClient{client: client, recorder: r}
+}
+
+type recordingClient struct {
+	client   LLMClient
+	recorder *CassetteRecorder
+}
+
+func (c *recordingClient) Model() string { return c.client.Model() }
+
+func (c *recordingClient) Generate(ctx context.Context, req GenerateRequest) (GenerateResponse, error) {
+	response, err := c.client.Generate(ctx, req)
+	if err != nil {
+		return response, err
+	}
+
+	entry := CassetteEntry{
+		Hash:     HashRequest(req),
+		Model:    c.client.Model(),
+		Request:  req,
+		Response: response,
+	}
+	if err := c.recorder.record(entry); err != nil {
+		return response, fmt.Errorf("cannot record response: %w", err)
+	}
+
+	return response, nil
+}
+
+// CassettePlayer serves the responses of a recorded cassette. Identical
+// requests are served in the order they were recorded.
+type CassettePlayer struct {
+	sync.Mutex
+
+	entries map[string][]CassetteEntry // Request hash->Remaining entries
+}
+
+func LoadCassette(filePath string) (*CassettePlayer, error) {
+	f, err := os.Open(filePath)
+	if err != nil {
+		return nil, fmt.Errorf("cannot open cassette: %w", err)
+	}
+	defer f.Close()
+
+	player := &CassettePlayer{entries: make(map[string][]CassetteEntry)}
+
+	scanner := bufio.NewScanner(f)
+	scanner.Buffer(nil, 16*1024*1024)
+	for line := 1; scanner.Scan(); line++ {
+		var entry CassetteEntry
+		if err := json.Unmarshal(scanner.Bytes(), &entry); err != nil {
+			return nil, fmt.Errorf("cannot load cassette: line %d: %w", line, err)
+		}
+		player.entries[entry.Hash] = append(player.entries[entry.Hash], entry)
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("cannot load cassette: %w", err)
+	}
+
+	return player, nil
+}
+
+func (p *CassettePlayer) next(hash string) (CassetteEntry, bool) {
+	p.Lock()
+	defer p.Unlock()
+
+	entries := p.entries[hash]
+	if len(entries) == 0 {
+		return CassetteEntry{}, false
+	}
+
+	p.entries[hash] = entries[1:]
+	return entries[0], true
+}
+
+// Client returns an [LLMClient] replaying responses from the cassette.
+//
+// A request without a matching recording fails with [ErrCassetteMismatch]:
+// the run has diverged from the recording and any further replies would be
+// meaningless, so agents and the council stop on it.
+func (p *CassettePlayer) Client() LLMClient {
+	return &replayClient{player: p}
+}
+
+type replayClient struct {
+	player *CassettePlayer
+}
+
+func (c *replayClient) Model() string { return "replay" }
+
+func (c *replayClient) Generate(ctx context.Context, req GenerateRequest) (GenerateResponse, error) {
+	hash := HashRequest(req)
+	entry, ok := c.player.next(hash)
+	if !ok {
+		return GenerateResponse{}, fmt.Errorf("%w: request hash %s", ErrCassetteMismatch, hash)
+	}
+
+	return entry.Response, nil
+}
@@ -0,0 +1,135 @@
+package internal
+
+import (
+	"encoding/json"
+	"fmt"
+	"maps"
+	"os"
+)
+
+// AgentCheckpoint is the state of a single council member.
+type AgentCheckpoint struct {
+	ID     string      `json:"id"`
+	Name   string      `json:"name,omitempty"` // Empty for anonymous agents
+	Memory MemoryState `json:"memory"`
+}
+
+// Checkpoint is the full state of a running simulation: the world, the
+// undelivered messages on the bus, the memory of each agent and the
+// council's proposals.
+//
+// A checkpoint is independent of the council it was taken from, so it can
+// be restored into any number of new councils to fork the simulation.
+// Forks may differ from the original in scenario, models or composition:
+// agents are matched by name, see [Checkpoint.AgentID], agents missing from
+// the checkpoint start with an empty memory, and checkpointed agents missing
+// from the fork are dropped along with their undelivered messages.
+type Checkpoint struct {
+	World     *WorldSnapshot             `json:"world"`
+	Messages  map[string][]Message       `json:"messages"` // Subscriber->Undelivered messages
+	Agents    []AgentCheckpoint          `json:"agents"`
+	Proposals map[string]*ProposalRecord `json:"proposals"`
+}
+
+func cloneProposals(proposals map[string]*ProposalRecord) map[string]*ProposalRecord {
+	cloned := make(map[string]*ProposalRecord, len(proposals))
+	for id, record := range proposals {
+		copied := *record
+		copied.Votes = maps.Clone(record.Votes)
+		cloned[id] = &copied
+	}
+	return cloned
+}
+
+// Checkpoint captures the state of the simulation. It must not be called
+// while the council is running, see [CouncilOptions.OnCheckpoint].
+func (c *Council) Checkpoint() (*Checkpoint, error) {
+	world, err := c.world.Snapshot()
+	if err != nil {
+		return nil, err
+	}
+
+	agents := make([]AgentCheckpoint, 0, len(c.agents))
+	for _, a := range c.agents {
+		agents = append(agents, AgentCheckpoint{ID: a.ID, Name: a.Name, Memory: a.memory.State()})
+	}
+
+	return &Checkpoint{
+		World:     world,
+		Messages:  c.bus.Buffers(),
+		Agents:    agents,
+		Proposals: cloneProposals(c.proposals),
+	}, nil
+}
+
+// Restore continues the simulation from the checkpoint. It must be called
+// after the council's agents are registered and before it is started.
+func (c *Council) Restore(cp *Checkpoint) error {
+	if err := c.world.Restore(cp.World); err != nil {
+		return fmt.Errorf("cannot restore world: %w", err)
+	}
+
+	c.bus.RestoreBuffers(cp.Messages)
+
+	memories := make(map[string]MemoryState, len(cp.Agents))
+	for _, agent := range cp.Agents {
+		memories[agent.ID] = agent.Memory
+	}
+	for _, a := range c.agents {
+		if memory, ok := memories[a.ID]; ok {
+			a.memory.Restore(memory)
+		}
+	}
+
+	c.proposals = cloneProposals(cp.Proposals)
+	c.resumed = true
+
+	return nil
+}
+
+// AgentID returns the ID of the checkpointed agent a fork's agent takes
+// over, given its name and position in the fork's council. Named agents are
+// matched by name, anonymous agents by position among the checkpointed
+// agents. ok is false for agents missing from the checkpoint.
+func (cp *Checkpoint) AgentID(name string, index int) (id string, ok bool) {
+	if name == "" {
+		if index < len(cp.Agents) && cp.Agents[index].Name == "" {
+			return cp.Agents[index].ID, true
+		}
+		return "", false
+	}
+
+	for _, agent := range cp.Agents {
+		if agent.Name == name {
+			return agent.ID, true
+		}
+	}
+	return "", false
+}
+
+// SaveToFile writes the checkpoint to the file, replacing it atomically.
+func (cp *Checkpoint) SaveToFile(filePath string) error {
+	data, err := json.Marshal(cp)
+	if err != nil {
+		return err
+	}
+
+	return writeFileAtomic(filePath, data)
+}
+
+func LoadCheckpointFromFile(filePath string) (*Checkpoint, error) {
+	data, err := os.ReadFile(filePath)
+	if err != nil {
+		return nil, err
+	}
+
+	var cp Checkpoint
+	if err := json.Unmarshal(data, &cp); err != nil {
+		return nil, fmt.Errorf("cannot load checkpoint: %w", err)
+	}
+	if cp.World == nil {
+		return nil, fmt.Errorf("cannot load checkpoint: missing world")
+	}
+
+	return &cp, nil
+}
@@ -0,0 +1,84 @@
+package internal
+
+import (
+	"context"
+	"errors"
+	"sync"
+)
+
+type command func(*World) error
+
+// Commands is a buffer of structural changes to a [World] deferred until
+// it is safe to apply them, i.e. after systems have finished iterating
+// over queries. Commands are applied in the order they were queued while
+// the world is locked by [World.Tick].
+type Commands struct {
+	sync.Mutex
+
+	queue []command
+}
+
+func NewCommands() *Commands {
+	return &Commands{}
+}
+
+func (c *Commands) push(cmd command) {
+	c.Lock()
+	defer c.Unlock()
+
+	c.queue = append(c.queue, cmd)
+}
+
+func (c *Commands) Spawn(components ...Component) {
+	c.push(func(w *World) error {
+		w.registerEntity(components...)
+		return nil
+	})
+}
+
+func (c *Commands) Despawn(entity EntityID) {
+	c.push(func(w *World) error { return w.despawn(entity) })
+}
+
+func (c *Commands) AddComponent(entity EntityID, component Component) {
+	c.push(func(w *World) error { return w.addComponent(entity, component) })
+}
+
+func (c *Commands) RemoveComponent(entity EntityID, component Component) {
+	c.push(func(w *World) error { return w.removeComponent(entity, component) })
+}
+
+// apply runs every queued command against the world and empties the
+// buffer. Failing commands do not prevent later commands from running.
+// The caller must hold the world's write lock.
+func (c *Commands) apply(w *World) error {
+	c.Lock()
+	queue := c.queue
+	c.queue = nil
+	c.Unlock()
+
+	var errs []error
+	for _, cmd := range queue {
+		errs = append(errs, cmd(w))
+	}
+	return errors.Join(errs...)
+}
+
+type commandsKey struct{}
+
+func withCommands(ctx context.Context, cmds *Commands) context.Context {
+	return context.WithValue(ctx, commandsKey{}, cmds)
+}
+
+// Commands returns the command buffer to queue structural changes on.
+//
+// Within a system's Update this is the system's own buffer, applied once
+// its stage completes, so changes from concurrent systems are applied in
+// a deterministic order. Elsewhere it is the world's buffer, applied at
+// the end of the next tick.
+func (w *World) Commands(ctx context.Context) *Commands {
+	if cmds, ok := ctx.Value(commandsKey{}).(*Commands); ok {
+		return cmds
+	}
+	return w.commands
+}
@@ -1,44 +1,272 @@
 package internal
 
-import "reflect"
+import (
+	"fmt"
+	"reflect"
+	"regexp"
+	"slices"
+	"strconv"
+	"strings"
+	"sync"
+)
 
 type Component any
 
 type ComponentID uint
 
-var (
-	CompReg                     = NewComponentRegistry()
-	nextComponentID ComponentID = 0
-)
+var CompReg = NewComponentRegistry()
+
+func init() {
+	CompReg.MustRegister("identity", 1, IdentityComponent{})
+	CompReg.MustRegister("stats", 1, StatComponent{})
+	CompReg.MustRegister("mood", 1, MoodComponent{})
+}
 
+// ComponentInfo describes a registered component type.
+type ComponentInfo struct {
+	Name    string         `json:"name"`    // Stable name used wherever components leave the process
+	Version int            `json:"version"` // Bumped whenever the fields change incompatibly
+	Schema  map[string]any `json:"schema"`  // JSON schema of the component's fields
+	Type    reflect.Type   `json:"-"`
+}
+
+var componentNamePattern = regexp.MustCompile(`^[A-Za-z0-9_.-]+$`)
+
+// ComponentRegistry assigns IDs to component types and holds the metadata
+// of registered components.
+//
+// IDs are process-local: registered components are numbered in
+// registration order and other types in first-seen order. Anything that
+// must be stable across runs, such as signature keys and snapshots, uses
+// registered names instead.
 type ComponentRegistry struct {
-	store map[reflect.Type]ComponentID
+	sync.RWMutex
+
+	store  map[reflect.Type]ComponentID
+	types  []reflect.Type                 // ComponentID->Go type
+	infos  map[ComponentID]*ComponentInfo // Metadata of registered components
+	byName map[string]ComponentID         // Registered name->ComponentID
 }
 
 func NewComponentRegistry() *ComponentRegistry {
-	return &ComponentRegistry{store: make(map[reflect.Type]ComponentID)}
+	return &ComponentRegistry{
+		store:  make(map[reflect.Type]ComponentID),
+		infos:  make(map[ComponentID]*ComponentInfo),
+		byName: make(map[string]ComponentID),
+	}
+}
+
+// Register assigns the component type a stable name and version and
+// derives the schema of its fields. See [componentSchema] for the struct
+// tags describing fields.
+func (r *ComponentRegistry) Register(name string, version int, c Component) error {
+	if !componentNamePattern.MatchString(name) {
+		return fmt.Errorf("invalid component name %q, must match %s", name, componentNamePattern)
+	}
+	if version <= 0 {
+		return fmt.Errorf("invalid version %d for component %q, must be positive", version, name)
+	}
+
+	t := reflect.TypeOf(c)
+	schema, err := componentSchema(t)
+	if err != nil {
+		return fmt.Errorf("component %q: %w", name, err)
+	}
+
+	r.Lock()
+	defer r.Unlock()
+
+	id := r.id(t)
+	if existing, ok := r.byName[name]; ok && existing != id {
+		return fmt.Errorf("component name %q is already registered for %s", name, r.types[existing])
+	}
+	if existing, ok := r.infos[id]; ok && existing.Name != name {
+		return fmt.Errorf("component %s is already registered as %q", t, existing.Name)
+	}
+
+	r.infos[id] = &ComponentInfo{Name: name, Version: version, Schema: schema, Type: t}
+	r.byName[name] = id
+	return nil
+}
+
+func (r *ComponentRegistry) MustRegister(name string, version int, c Component) {
+	if err := r.Register(name, version, c); err != nil {
+		panic(err)
+	}
+}
+
+// Name returns the registered name of the component ID.
+func (r *ComponentRegistry) Name(id ComponentID) (string, bool) {
+	r.RLock()
+	defer r.RUnlock()
+
+	info, ok := r.infos[id]
+	if !ok {
+		return "", false
+	}
+	return info.Name, true
+}
+
+// Lookup returns the Go type of the registered component name.
+func (r *ComponentRegistry) Lookup(name string) (reflect.Type, bool) {
+	info, ok := r.Info(name)
+	return info.Type, ok
+}
+
+// Info returns the metadata of the registered component name.
+func (r *ComponentRegistry) Info(name string) (ComponentInfo, bool) {
+	r.RLock()
+	defer r.RUnlock()
+
+	id, ok := r.byName[name]
+	if !ok {
+		return ComponentInfo{}, false
+	}
+	return *r.infos[id], true
+}
+
+// Components returns the metadata of every registered component, ordered
+// by name.
+func (r *ComponentRegistry) Components() []ComponentInfo {
+	r.RLock()
+	defer r.RUnlock()
+
+	infos := make([]ComponentInfo, 0, len(r.infos))
+	for _, info := range r.infos {
+		infos = append(infos, *info)
+	}
+	slices.SortFunc(infos, func(a, b ComponentInfo) int { return strings.Compare(a.Name, b.Name) })
+	return infos
+}
+
+// key returns the name of the component ID within signature keys. Names
+// cannot contain '#' or ',', so unregistered components never collide
+// with registered ones or each other.
+func (r *ComponentRegistry) key(id ComponentID) string {
+	if name, ok := r.Name(id); ok {
+		return name
+	}
+	return fmt.Sprintf("#%d", id)
 }
 
 func (r *ComponentRegistry) GetComponentID(c Component) ComponentID {
 	t := reflect.TypeOf(c)
+
+	r.RLock()
+	id, ok := r.store[t]
+	r.RUnlock()
+	if ok {
+		return id
+	}
+
+	r.Lock()
+	defer r.Unlock()
+
+	return r.id(t)
+}
+
+// id returns the ID of the type, assigning the next one if it has none.
+// The caller must hold the write lock.
+func (r *ComponentRegistry) id(t reflect.Type) ComponentID {
 	if id, ok := r.store[t]; ok {
 		return id
 	}
 
-	id := nextComponentID
+	id := ComponentID(len(r.types))
 	r.store[t] = id
-	nextComponentID++
+	r.types = append(r.types, t)
 	return id
 }
 
+// componentSchema derives the JSON schema of a component struct from its
+// exported fields. Fields are named by their json tag and may be
+// described further with tags:
+//
+//	Age int `json:"age" min:"0" max:"100" desc:"Age in years"`
+func componentSchema(t reflect.Type) (map[string]any, error) {
+	if t == nil || t.Kind() != reflect.Struct {
+		return nil, fmt.Errorf("components must be structs, got %v", t)
+	}
+
+	properties := map[string]any{}
+	required := []string{}
+	for i := range t.NumField() {
+		field := t.Field(i)
+		if !field.IsExported() {
+			continue
+		}
+
+		name, _, _ := strings.Cut(field.Tag.Get("json"), ",")
+		if name == "-" {
+			continue
+		}
+		if name == "" {
+			name = field.Name
+		}
+
+		schema, err := fieldSchema(field.Type)
+		if err != nil {
+			return nil, fmt.Errorf("field %s: %w", field.Name, err)
+		}
+		if desc := field.Tag.Get("desc"); desc != "" {
+			schema["description"] = desc
+		}
+		for tag, keyword := range map[string]string{"min": "minimum", "max": "maximum"} {
+			value := field.Tag.Get(tag)
+			if value == "" {
+				continue
+			}
+			bound, err := strconv.ParseFloat(value, 64)
+			if err != nil {
+				return nil, fmt.Errorf("field %s: invalid %s tag %q", field.Name, tag, value)
+			}
+			schema[keyword] = bound
+		}
+
+		properties[name] = schema
+		required = append(required, name)
+	}
+
+	return map[string]any{
+		"type":                 "object",
+		"properties":           properties,
+		"required":             required,
+		"additionalProperties": false,
+	}, nil
+}
+
+func fieldSchema(t reflect.Type) (map[string]any, error) {
+	switch t.Kind() {
+	case reflect.Bool:
+		return map[string]any{"type": "boolean"}, nil
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return map[string]any{"type": "integer"}, nil
+	case reflect.Float32, reflect.Float64:
+		return map[string]any{"type": "number"}, nil
+	case reflect.String:
+		return map[string]any{"type": "string"}, nil
+	case reflect.Slice, reflect.Array:
+		items, err := fieldSchema(t.Elem())
+		if err != nil {
+			return nil, err
+		}
+		return map[string]any{"type": "array", "items": items}, nil
+	case reflect.Struct:
+		return componentSchema(t)
+	default:
+		return nil, fmt.Errorf("unsupported field type %s", t)
+	}
+}
+
 type IdentityComponent struct {
-	Name string
-	Age  int // 0 - 100
+	Name string `json:"name"`
+	Age  int    `json:"age" min:"0" max:"100" desc:"Age in years"`
 }
 
 type StatComponent struct {
-	Health int // 0 - 100
-	Money  int
+	Health int `json:"health" min:"0" max:"100"`
+	Money  int `json:"money" desc:"Wealth in dollars"`
 	// Hunger
 	// Energy
 	// Stress
@@ -46,7 +274,7 @@ type StatComponent struct {
 }
 
 type MoodComponent struct {
-	Happiness int // 0 - 100
+	Happiness int `json:"happiness" min:"0" max:"100"`
 	// Anger
 	// etc.
 }
@@ -0,0 +1,454 @@
+package internal
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"reflect"
+	"regexp"
+	"slices"
+	"strconv"
+	"strings"
+
+	"github.com/google/uuid"
+	"gopkg.in/yaml.v3"
+)
+
+// Paths are config files, written as a single path or a list of paths.
+type Paths []string
+
+func (p *Paths) UnmarshalJSON(data []byte) error {
+	var path string
+	if json.Unmarshal(data, &path) == nil {
+		*p = Paths{path}
+		return nil
+	}
+
+	var paths []string
+	if err := json.Unmarshal(data, &paths); err != nil {
+		return errors.New("expected a path or a list of paths")
+	}
+	*p = paths
+	return nil
+}
+
+func (p *Paths) UnmarshalYAML(node *yaml.Node) error {
+	if node.Kind == yaml.ScalarNode {
+		*p = Paths{node.Value}
+		return nil
+	}
+
+	var paths []string
+	if err := node.Decode(&paths); err != nil {
+		return errors.New("expected a path or a list of paths")
+	}
+	*p = paths
+	return nil
+}
+
+// Override sets a single field of a simulation config on top of the files
+// it is composed of.
+type Override struct {
+	Path   []string // Keys and indices leading to the field, e.g. ["agents", "0", "model"]
+	Value  any
+	Source string // Where the override comes from, e.g. "-set", reported in errors
+}
+
+// ParseOverride parses an override written as path=value, where the path
+// is dotted, e.g. population.size=500 or agents.0.model=gpt-5. The value is
+// parsed as YAML, so numbers, booleans and lists keep their type.
+func ParseOverride(s string) (Override, error) {
+	path, value, ok := strings.Cut(s, "=")
+	if !ok || path == "" {
+		return Override{}, fmt.Errorf("invalid override %q, expected path=value", s)
+	}
+
+	var v any
+	if err := yaml.Unmarshal([]byte(value), &v); err != nil {
+		return Override{}, fmt.Errorf("invalid override %q: %w", s, err)
+	}
+	return Override{Path: strings.Split(path, "."), Value: v}, nil
+}
+
+func (o Override) source() string {
+	if o.Source == "" {
+		return "override"
+	}
+	return o.Source
+}
+
+func (o Override) error(err error) error {
+	return &ConfigError{File: o.source(), Field: strings.Join(o.Path, "."), Err: err}
+}
+
+// apply sets the field in the config tree, creating missing mappings along
+// the way. Overrides are checked against the config format up front, since
+// the merged tree is not decoded strictly.
+func (o Override) apply(root *yaml.Node, origins map[*yaml.Node]string) error {
+	if len(o.Path) == 0 {
+		return o.error(errors.New("empty path"))
+	}
+
+	t := reflect.TypeFor[Simulation]()
+	for _, segment := range o.Path {
+		var ok bool
+		if t, ok = fieldType(t, segment); !ok {
+			return o.error(fmt.Errorf("unknown field %q", segment))
+		}
+	}
+
+	value := new(yaml.Node)
+	if err := value.Encode(o.Value); err != nil {
+		return o.error(err)
+	}
+	if err := value.Decode(reflect.New(t).Interface()); err != nil {
+		var typeErr *yaml.TypeError
+		if errors.As(err, &typeErr) {
+			// The value has no position, so drop the line yaml reports
+			errs := make([]error, len(typeErr.Errors))
+			for i, msg := range typeErr.Errors {
+				errs[i] = errors.New(yamlLinePattern.ReplaceAllString(msg, "$2"))
+			}
+			err = errors.Join(errs...)
+		}
+		return o.error(err)
+	}
+	recordOrigin(value, o.source(), origins)
+
+	node := root
+	for i, segment := range o.Path {
+		last := i == len(o.Path)-1
+		if node.Kind == yaml.ScalarNode && node.Tag == "!!null" {
+			*node = yaml.Node{Kind: yaml.MappingNode, Tag: "!!map"}
+		}
+
+		switch node.Kind {
+		case yaml.MappingNode:
+			idx := mappingIndex(node, segment)
+			if idx < 0 {
+				key := &yaml.Node{Kind: yaml.ScalarNode, Tag: "!!str", Value: segment}
+				next := &yaml.Node{Kind: yaml.MappingNode, Tag: "!!map"}
+				recordOrigin(key, o.source(), origins)
+				recordOrigin(next, o.source(), origins)
+				node.Content = append(node.Content, key, next)
+				idx = len(node.Content) - 2
+			}
+			if last {
+				node.Content[idx+1] = value
+			}
+			node = node.Content[idx+1]
+		case yaml.SequenceNode:
+			idx, err := strconv.Atoi(segment)
+			if err != nil || idx < 0 || idx >= len(node.Content) {
+				return o.error(fmt.Errorf("index %s out of range, %s has %d elements", segment, strings.Join(o.Path[:i], "."), len(node.Content)))
+			}
+			if last {
+				node.Content[idx] = value
+			}
+			node = node.Content[idx]
+		default:
+			return o.error(fmt.Errorf("%s is not a mapping or a list", strings.Join(o.Path[:i], ".")))
+		}
+	}
+	return nil
+}
+
+// fieldType returns the type of the field or element named by segment in
+// values of type t, by their yaml tags.
+func fieldType(t reflect.Type, segment string) (reflect.Type, bool) {
+	for t.Kind() == reflect.Pointer {
+		t = t.Elem()
+	}
+
+	switch t.Kind() {
+	case reflect.Struct:
+		for i := range t.NumField() {
+			field := t.Field(i)
+			name, opts, _ := strings.Cut(field.Tag.Get("yaml"), ",")
+			if strings.Contains(opts, "inline") {
+				if ft, ok := fieldType(field.Type, segment); ok {
+					return ft, true
+				}
+				continue
+			}
+			if field.IsExported() && name == segment {
+				return field.Type, true
+			}
+		}
+	case reflect.Slice, reflect.Array:
+		if _, err := strconv.Atoi(segment); err == nil {
+			return t.Elem(), true
+		}
+	case reflect.Map:
+		return t.Elem(), true
+	case reflect.Interface:
+		return t, true
+	}
+	return nil, false
+}
+
+var envPattern = regexp.MustCompile(`\$\$|\$\{([A-Za-z_][A-Za-z0-9_]*)(:-([^}]*))?\}`)
+
+// interpolateEnv replaces each ${VAR} in the values of the tree with the
+// value of the environment variable, and ${VAR:-default} with default when
+// the variable is unset or empty. $$ is a literal $. Keys and comments are
+// left as written, and values cannot add structure to the tree.
+func interpolateEnv(filePath string, node *yaml.Node) error {
+	var errs []error
+	switch node.Kind {
+	case yaml.ScalarNode:
+		if !strings.Contains(node.Value, "$") {
+			return nil
+		}
+
+		var sb strings.Builder
+		last := 0
+		for _, match := range envPattern.FindAllStringSubmatchIndex(node.Value, -1) {
+			sb.WriteString(node.Value[last:match[0]])
+			last = match[1]
+
+			if match[2] < 0 {
+				sb.WriteByte('$')
+				continue
+			}
+
+			name := node.Value[match[2]:match[3]]
+			value := os.Getenv(name)
+			switch {
+			case value != "":
+			case match[4] >= 0:
+				value = node.Value[match[6]:match[7]]
+			default:
+				errs = append(errs, &ConfigError{
+					File:   filePath,
+					Line:   node.Line,
+					Column: node.Column,
+					Err:    fmt.Errorf("environment variable %s is not set", name),
+				})
+			}
+			sb.WriteString(value)
+		}
+		sb.WriteString(node.Value[last:])
+		node.Value = sb.String()
+
+		// Plain values are resolved again, so ${SIZE} may be a number
+		if node.Style == 0 {
+			node.Tag = ""
+			node.Tag = node.ShortTag()
+		}
+	case yaml.MappingNode:
+		for i := 1; i < len(node.Content); i += 2 {
+			errs = append(errs, interpolateEnv(filePath, node.Content[i]))
+		}
+	default:
+		for _, child := range node.Content {
+			errs = append(errs, interpolateEnv(filePath, child))
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// unknownFields reports each key of the mappings in the tree that is not a
+// field of values of type t, by their yaml tags. path leads to the node.
+func unknownFields(filePath string, node *yaml.Node, t reflect.Type, path []any) []error {
+	for t.Kind() == reflect.Pointer {
+		t = t.Elem()
+	}
+	if reflect.PointerTo(t).Implements(reflect.TypeFor[yaml.Unmarshaler]()) {
+		return nil
+	}
+
+	var errs []error
+	switch {
+	case node.Kind == yaml.MappingNode && (t.Kind() == reflect.Struct || t.Kind() == reflect.Map):
+		for i := 0; i+1 < len(node.Content); i += 2 {
+			key := node.Content[i]
+			at := append(slices.Clip(path), any(key.Value))
+			ft, ok := fieldType(t, key.Value)
+			if !ok {
+				errs = append(errs, &ConfigError{
+					File:   filePath,
+					Line:   key.Line,
+					Column: key.Column,
+					Field:  (&FieldError{Path: at}).Field(),
+					Err:    errors.New("unknown field"),
+				})
+				continue
+			}
+			errs = append(errs, unknownFields(filePath, node.Content[i+1], ft, at)...)
+		}
+	case node.Kind == yaml.SequenceNode && (t.Kind() == reflect.Slice || t.Kind() == reflect.Array):
+		for i, elem := range node.Content {
+			errs = append(errs, unknownFields(filePath, elem, t.Elem(), append(slices.Clip(path), i))...)
+		}
+	}
+	return errs
+}
+
+func recordOrigin(node *yaml.Node, filePath string, origins map[*yaml.Node]string) {
+	origins[node] = filePath
+	for _, child := range node.Content {
+		recordOrigin(child, filePath, origins)
+	}
+}
+
+// mappingIndex returns the index of the key in the mapping node's content,
+// or -1 if the key is missing.
+func mappingIndex(node *yaml.Node, key string) int {
+	for i := 0; i+1 < len(node.Content); i += 2 {
+		if node.Content[i].Value == key {
+			return i
+		}
+	}
+	return -1
+}
+
+// mergeNodes deep merges overlay into base: mappings are merged key by key,
+// while the scalars and lists of overlay replace those of base.
+func mergeNodes(base, overlay *yaml.Node) *yaml.Node {
+	if base == nil || base.Kind != yaml.MappingNode || overlay.Kind != yaml.MappingNode {
+		return overlay
+	}
+
+	for i := 0; i+1 < len(overlay.Content); i += 2 {
+		key, value := overlay.Content[i], overlay.Content[i+1]
+		if idx := mappingIndex(base, key.Value); idx >= 0 {
+			base.Content[idx+1] = mergeNodes(base.Content[idx+1], value)
+		} else {
+			base.Content = append(base.Content, key, value)
+		}
+	}
+	return base
+}
+
+// loadConfigFile strictly decodes the config in the file and returns its
+// tree, with the configs it extends and includes merged beneath it. Each
+// node is recorded in origins with the file it comes from. loading holds
+// the files including this one, to detect cycles.
+func loadConfigFile(filePath string, origins map[*yaml.Node]string, loading []string) (*yaml.Node, error) {
+	switch filepath.Ext(filePath) {
+	case ".yaml", ".yml", ".json":
+	default:
+		return nil, fmt.Errorf("cannot load simulation %s: unknown format, expected .yaml, .yml or .json", filePath)
+	}
+
+	data, err := os.ReadFile(filePath)
+	if err != nil {
+		return nil, err
+	}
+
+	// JSON is also YAML, so both formats are parsed as YAML to merge them
+	// and locate errors
+	var doc yaml.Node
+	if err := yaml.Unmarshal(data, &doc); err != nil {
+		return nil, yamlError(filePath, err)
+	}
+	root := &yaml.Node{Kind: yaml.MappingNode, Tag: "!!map"}
+	if len(doc.Content) > 0 && doc.Content[0].Kind == yaml.MappingNode {
+		root = doc.Content[0]
+	}
+	if err := interpolateEnv(filePath, root); err != nil {
+		return nil, err
+	}
+
+	// Every file is decoded strictly on its own first, so unknown fields and
+	// mistyped values are reported where they are written
+	errs := unknownFields(filePath, root, reflect.TypeFor[Simulation](), nil)
+	var sim Simulation
+	if err := root.Decode(&sim); err != nil {
+		errs = append(errs, yamlError(filePath, err))
+	}
+	if len(errs) > 0 {
+		return nil, errors.Join(errs...)
+	}
+	recordOrigin(root, filePath, origins)
+
+	abs, err := filepath.Abs(filePath)
+	if err != nil {
+		return nil, err
+	}
+	loading = append(slices.Clip(loading), abs)
+
+	var merged *yaml.Node
+	for _, field := range []struct {
+		name  string
+		paths Paths
+	}{{"extends", sim.Extends}, {"include", sim.Include}} {
+		for idx, path := range field.paths {
+			if !filepath.IsAbs(path) {
+				path = filepath.Join(filepath.Dir(filePath), path)
+			}
+
+			var node *yaml.Node
+			if other, _ := filepath.Abs(path); slices.Contains(loading, other) {
+				err = fmt.Errorf("%s would include itself", path)
+			} else {
+				node, err = loadConfigFile(path, origins, loading)
+			}
+			if err != nil {
+				var configErr *ConfigError
+				if errors.As(err, &configErr) {
+					return nil, err
+				}
+				configErr = &ConfigError{File: filePath, Field: field.name, Err: err}
+				at := locate(root, []any{field.name, idx})
+				configErr.Line, configErr.Column = at.Line, at.Column
+				return nil, configErr
+			}
+			merged = mergeNodes(merged, node)
+		}
+	}
+
+	for _, key := range []string{"extends", "include"} {
+		if idx := mappingIndex(root, key); idx >= 0 {
+			root.Content = slices.Delete(root.Content, idx, idx+2)
+		}
+	}
+	return mergeNodes(merged, root), nil
+}
+
+// LoadSimulation composes the simulation config from the files, each deep
+// merged over the previous ones, and the overrides applied on top, then
+// validates it. Mappings are merged key by key, while scalars and lists
+// replace those beneath them. Every problem found is reported as a
+// [ConfigError] locating it in the file or override it comes from.
+func LoadSimulation(files []string, overrides ...Override) (*Simulation, error) {
+	origins := make(map[*yaml.Node]string)
+	var root *yaml.Node
+	for _, file := range files {
+		node, err := loadConfigFile(file, origins, nil)
+		if err != nil {
+			return nil, err
+		}
+		root = mergeNodes(root, node)
+	}
+	if root == nil {
+		root = &yaml.Node{Kind: yaml.MappingNode, Tag: "!!map"}
+	}
+
+	for _, o := range overrides {
+		if err := o.apply(root, origins); err != nil {
+			return nil, err
+		}
+	}
+
+	var sim Simulation
+	if err := root.Decode(&sim); err != nil {
+		return nil, fmt.Errorf("cannot decode merged simulation config: %w", err)
+	}
+
+	if err := sim.Validate(); err != nil {
+		return nil, locateErrors(root, origins, err)
+	}
+
+	sim.id = uuid.NewString()
+	return &sim, nil
+}
+
+// LoadSimulationFromFile loads the simulation config in a single file, see
+// [LoadSimulation].
+func LoadSimulationFromFile(filePath string) (*Simulation, error) {
+	return LoadSimulation([]string{filePath})
+}
@@ -0,0 +1,344 @@
+package internal
+
+import (
+	"errors"
+	"fmt"
+	"reflect"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+
+	"gopkg.in/yaml.v3"
+)
+
+// FieldError is a problem with a single field of a simulation config.
+type FieldError struct {
+	Path []any // Keys and indices leading to the field, e.g. ["agents", 1, "name"]
+	Err  error
+}
+
+func fieldError(err error, path ...any) *FieldError {
+	return &FieldError{Path: path, Err: err}
+}
+
+func (e *FieldError) Field() string {
+	var sb strings.Builder
+	for _, segment := range e.Path {
+		switch s := segment.(type) {
+		case int:
+			fmt.Fprintf(&sb, "[%d]", s)
+		default:
+			if sb.Len() > 0 {
+				sb.WriteRune('.')
+			}
+			fmt.Fprint(&sb, s)
+		}
+	}
+	return sb.String()
+}
+
+func (e *FieldError) Error() string { return fmt.Sprintf("%s: %s", e.Field(), e.Err) }
+func (e *FieldError) Unwrap() error { return e.Err }
+
+// ConfigError is a problem with a simulation config, located in the file
+// or override it comes from.
+type ConfigError struct {
+	File   string // The file or override, e.g. "-set"
+	Line   int    // 1-based, 0 if unknown
+	Column int    // 1-based, 0 if unknown
+	Field  string // The offending field, empty if unknown
+	Err    error
+}
+
+func (e *ConfigError) Error() string {
+	var sb strings.Builder
+	if e.File != "" {
+		sb.WriteString(e.File)
+		if e.Line > 0 {
+			fmt.Fprintf(&sb, ":%d", e.Line)
+			if e.Column > 0 {
+				fmt.Fprintf(&sb, ":%d", e.Column)
+			}
+		}
+		sb.WriteString(": ")
+	}
+	if e.Field != "" {
+		fmt.Fprintf(&sb, "%s: ", e.Field)
+	}
+	sb.WriteString(e.Err.Error())
+	return sb.String()
+}
+
+func (e *ConfigError) Unwrap() error { return e.Err }
+
+// locate returns the node of the field at the path, or the closest
+// ancestor present in the document.
+func locate(node *yaml.Node, path []any) *yaml.Node {
+	if node.Kind == yaml.DocumentNode && len(node.Content) > 0 {
+		node = node.Content[0]
+	}
+
+	for _, segment := range path {
+		var next *yaml.Node
+		switch s := segment.(type) {
+		case int:
+			if node.Kind == yaml.SequenceNode && s < len(node.Content) {
+				next = node.Content[s]
+			}
+		case string:
+			if node.Kind == yaml.MappingNode {
+				if idx := mappingIndex(node, s); idx >= 0 {
+					next = node.Content[idx+1]
+				}
+			}
+		}
+		if next == nil {
+			return node
+		}
+		node = next
+	}
+	return node
+}
+
+// locateErrors attaches the origin and position of each field error in err
+// found in the config tree.
+func locateErrors(root *yaml.Node, origins map[*yaml.Node]string, err error) error {
+	var errs []error
+	for _, e := range flatten(err) {
+		var fieldErr *FieldError
+		if !errors.As(e, &fieldErr) {
+			errs = append(errs, &ConfigError{Err: e})
+			continue
+		}
+
+		node := locate(root, fieldErr.Path)
+		errs = append(errs, &ConfigError{
+			File:   origins[node],
+			Line:   node.Line,
+			Column: node.Column,
+			Field:  fieldErr.Field(),
+			Err:    fieldErr.Err,
+		})
+	}
+	return errors.Join(errs...)
+}
+
+func flatten(err error) []error {
+	if joined, ok := err.(interface{ Unwrap() []error }); ok {
+		var errs []error
+		for _, e := range joined.Unwrap() {
+			errs = append(errs, flatten(e)...)
+		}
+		return errs
+	}
+	return []error{err}
+}
+
+var yamlLinePattern = regexp.MustCompile(`^line (\d+): (.*)$`)
+
+// yamlError converts an error decoding the file, reporting each syntax
+// error or mistyped value with its line.
+func yamlError(filePath string, err error) error {
+	var typeErr *yaml.TypeError
+	if !errors.As(err, &typeErr) {
+		configErr := &ConfigError{File: filePath, Err: err}
+		if match := yamlLinePattern.FindStringSubmatch(strings.TrimPrefix(err.Error(), "yaml: ")); match != nil {
+			configErr.Line, _ = strconv.Atoi(match[1])
+			configErr.Err = errors.New(match[2])
+		}
+		return configErr
+	}
+
+	var errs []error
+	for _, msg := range typeErr.Errors {
+		configErr := &ConfigError{File: filePath, Err: errors.New(msg)}
+		if match := yamlLinePattern.FindStringSubmatch(msg); match != nil {
+			configErr.Line, _ = strconv.Atoi(match[1])
+			configErr.Err = errors.New(match[2])
+		}
+		errs = append(errs, configErr)
+	}
+	return errors.Join(errs...)
+}
+
+// Validate checks the simulation config for semantic errors, returning
+// every [FieldError] found.
+func (s *Simulation) Validate() error {
+	var errs []error
+
+	if strings.TrimSpace(s.Scenario) == "" {
+		errs = append(errs, fieldError(errors.New("cannot be empty"), "scenario"))
+	}
+
+	if p := s.Population; p != nil {
+		if p.Size != nil && *p.Size <= 0 {
+			errs = append(errs, fieldError(fmt.Errorf("must be positive, got %d", *p.Size), "population", "size"))
+		}
+		for _, field := range []struct {
+			name string
+			d    *Distribution
+		}{{"age", p.Age}, {"wealth", p.Wealth}, {"health", p.Health}, {"happiness", p.Happiness}} {
+			if field.d == nil {
+				continue
+			}
+			if err := field.d.Validate(); err != nil {
+				errs = append(errs, fieldError(err, "population", field.name))
+			}
+		}
+	}
+
+	errs = append(errs, s.World.validate()...)
+
+	names := make(map[string]int, len(s.Agents))
+	for idx, agent := range s.Agents {
+		if err := agent.Validate(); err != nil {
+			errs = append(errs, fieldError(err, "agents", idx))
+		}
+		if first, ok := names[agent.Name]; ok && agent.Name != "" {
+			errs = append(errs, fieldError(fmt.Errorf("duplicate name %q, already used by agent %d", agent.Name, first), "agents", idx, "name"))
+		}
+		names[agent.Name] = idx
+	}
+
+	return errors.Join(errs...)
+}
+
+func (c WorldConfig) validate() []error {
+	var errs []error
+
+	if c.TickDuration < 0 {
+		errs = append(errs, fieldError(fmt.Errorf("must not be negative, got %s", time.Duration(c.TickDuration)), "world", "tickDuration"))
+	}
+	if c.TimePerTick < 0 {
+		errs = append(errs, fieldError(fmt.Errorf("must not be negative, got %s", time.Duration(c.TimePerTick)), "world", "timePerTick"))
+	}
+	if c.ObserveEvery < 0 {
+		errs = append(errs, fieldError(fmt.Errorf("must not be negative, got %d", c.ObserveEvery), "world", "observeEvery"))
+	}
+
+	// Inputs are set by tools named after them, which must be unique too
+	inputs := make(map[string]bool, len(c.Inputs))
+	tools := make(map[string]string, len(c.Inputs))
+	for idx, in := range c.Inputs {
+		tool := InputToolName(in.Name)
+		other, collides := tools[tool]
+		switch {
+		case in.Name == "":
+			errs = append(errs, fieldError(errors.New("cannot be empty"), "world", "inputs", idx, "name"))
+		case inputs[in.Name]:
+			errs = append(errs, fieldError(fmt.Errorf("duplicate input %q", in.Name), "world", "inputs", idx, "name"))
+		case collides:
+			errs = append(errs, fieldError(fmt.Errorf("input %q has the same tool name %q as input %q", in.Name, tool, other), "world", "inputs", idx, "name"))
+		default:
+			tools[tool] = in.Name
+		}
+		inputs[in.Name] = true
+
+		if err := in.InputSpec.Validate(); err != nil {
+			errs = append(errs, fieldError(err, "world", "inputs", idx))
+		}
+	}
+
+	systems := make(map[string]bool, len(c.Systems))
+	for idx, sc := range c.Systems {
+		if systems[sc.Name] {
+			errs = append(errs, fieldError(fmt.Errorf("duplicate system %q", sc.Name), "world", "systems", idx, "name"))
+		}
+		systems[sc.Name] = true
+
+		if _, err := SysReg.New(sc.Name, sc.Params); err != nil {
+			errs = append(errs, fieldError(err, "world", "systems", idx))
+		}
+	}
+
+	return errs
+}
+
+// SimulationSchema returns the JSON schema of the simulation config
+// format, for editors and external validation.
+func SimulationSchema() map[string]any {
+	schema := configSchema(reflect.TypeFor[Simulation]())
+	schema["$schema"] = "https://json-schema.org/draft/2020-12/schema"
+	schema["title"] = "Simulation"
+	return schema
+}
+
+// configEnums lists the values of the string types used in configs.
+var configEnums = map[reflect.Type][]string{
+	reflect.TypeFor[DistributionKind](): {string(DistributionConstant), string(DistributionUniform), string(DistributionNormal), string(DistributionLogNormal), string(DistributionPareto)},
+	reflect.TypeFor[InputKind]():        {string(InputFloat), string(InputInt), string(InputEnum), string(InputBool), string(InputPercentage)},
+	reflect.TypeFor[ReasoningEffort]():  {string(ReasoningEffortMinimal), string(ReasoningEffortLow), string(ReasoningEffortMedium), string(ReasoningEffortHigh)},
+}
+
+// configSchema derives the JSON schema of a config type from its json
+// tags. Fields without omitempty are required.
+func configSchema(t reflect.Type) map[string]any {
+	if t == reflect.TypeFor[Paths]() {
+		return map[string]any{"oneOf": []any{
+			map[string]any{"type": "string"},
+			map[string]any{"type": "array", "items": map[string]any{"type": "string"}},
+		}}
+	}
+	if t == reflect.TypeFor[Duration]() {
+		return map[string]any{"type": "string", "pattern": `^(-?\d+(\.\d+)?(ns|us|µs|ms|s|m|h))+$`, "description": `A duration such as "1m30s"`}
+	}
+	if values, ok := configEnums[t]; ok {
+		return map[string]any{"type": "string", "enum": values}
+	}
+
+	switch t.Kind() {
+	case reflect.Pointer:
+		return configSchema(t.Elem())
+	case reflect.Interface:
+		return map[string]any{}
+	case reflect.Map:
+		return map[string]any{"type": "object", "additionalProperties": configSchema(t.Elem())}
+	case reflect.Slice, reflect.Array:
+		return map[string]any{"type": "array", "items": configSchema(t.Elem())}
+	case reflect.Struct:
+		properties := map[string]any{}
+		required := []string{}
+		addStructFields(t, properties, &required)
+
+		schema := map[string]any{
+			"type":                 "object",
+			"properties":           properties,
+			"additionalProperties": false,
+		}
+		if len(required) > 0 {
+			schema["required"] = required
+		}
+		return schema
+	default:
+		schema, err := fieldSchema(t)
+		if err != nil {
+			return map[string]any{}
+		}
+		return schema
+	}
+}
+
+func addStructFields(t reflect.Type, properties map[string]any, required *[]string) {
+	for i := range t.NumField() {
+		field := t.Field(i)
+		tag, hasTag := field.Tag.Lookup("json")
+		if field.Anonymous && !hasTag {
+			addStructFields(field.Type, properties, required) // Embedded structs are inlined
+			continue
+		}
+		if !field.IsExported() || tag == "-" {
+			continue
+		}
+
+		name, opts, _ := strings.Cut(tag, ",")
+		if name == "" {
+			name = field.Name
+		}
+
+		properties[name] = configSchema(field.Type)
+		if !strings.Contains(opts, "omitempty") {
+			*required = append(*required, name)
+		}
+	}
+}
@@ -3,63 +3,206 @@ package internal
 import (
 	"context"
 	"fmt"
+	"log/slog"
+	"strings"
 )
 
 type CouncilOptions struct {
 	MaxRounds int
+	MaxCycles int  // Observations after which the council adjourns, unlimited if 0
+	RNG       *RNG // The council's random stream, used for message IDs. Randomly seeded if nil
+
+	// Advance, if set, is called before each observation to step the
+	// world, so the council observes the same ticks on every run rather
+	// than whichever ticks a concurrently running world has reached.
+	Advance func(context.Context)
+
+	// OnCheckpoint, if set, is called once with a checkpoint of the
+	// simulation taken at the start of the first council cycle observing
+	// tick CheckpointAt or later, e.g. to fork it.
+	CheckpointAt int64
+	OnCheckpoint func(context.Context, *Checkpoint)
+}
+
+type ProposalStatus string
+
+const (
+	ProposalOpen     ProposalStatus = "open"
+	ProposalPassed   ProposalStatus = "passed"
+	ProposalRejected ProposalStatus = "rejected"
+)
+
+// ProposalRecord tracks a proposal put forward to the council and the
+// votes cast on it, keyed by agent ID.
+type ProposalRecord struct {
+	Proposal
+	Author string          `json:"author"`
+	Status ProposalStatus  `json:"status"`
+	Votes  map[string]Vote `json:"votes"`
 }
 
 type Council struct {
-	agents map[string]*Agent
-	bus    MessageBus
-	world  *World
+	agents    []*Agent // Agents in registration order, which is the order they speak in
+	bus       MessageBus
+	world     *World
+	proposals map[string]*ProposalRecord
+
+	resumed      bool // The council was restored from a checkpoint and skips its init message
+	checkpointed bool
 
 	opts CouncilOptions
 }
 
 func NewCouncil(bus MessageBus, w *World, opts CouncilOptions) *Council {
+	if opts.RNG == nil {
+		opts.RNG = randomRNG()
+	}
+
 	return &Council{
-		agents: make(map[string]*Agent),
-		bus:    bus,
-		world:  w,
-		opts:   opts,
+		bus:       bus,
+		world:     w,
+		proposals: make(map[string]*ProposalRecord),
+		opts:      opts,
 	}
 }
 
 func (c *Council) RegisterAgents(agents ...*Agent) *Council {
-	for _, a := range agents {
-		c.agents[a.ID] = a
-	}
+	c.agents = append(c.agents, agents...)
 	return c
 }
 
 func (c *Council) AgentCount() int { return len(c.agents) }
 
 func (c *Council) initMessage() string {
+	members := make([]string, 0, len(c.agents))
+	for _, a := range c.agents {
+		members = append(members, a.label())
+	}
+
 	return new(PromptBuilder).
 		WithTask(
 			"Begin the simulation.",
 			WithItems(
-				fmt.Sprintf("There are %d total agents on the council.", c.AgentCount()),
+				fmt.Sprintf("There are %d total agents on the council: %s.", c.AgentCount(), strings.Join(members, ", ")),
 				fmt.Sprintf("You have at most %d rounds of discussion before the next world state observation.", c.opts.MaxRounds),
 				"The world does not stop during council deliberations.",
+				"Proposals pass once a majority of the council votes yes, and their actions are then applied to the world.",
 			),
 		).
 		Build()
 }
 
-func (c *Council) Start(ctx context.Context) {
-	c.bus.Publish(ctx, "<system>", c.initMessage())
+func (c *Council) applyActions(ctx context.Context, agent string, actions []Action) {
+	for _, action := range actions {
+		if err := c.world.ApplyAction(action); err != nil {
+			slog.WarnContext(ctx, "failed to apply action", "agent", agent, "input", action.InputName, "error", err)
+		}
+	}
+}
+
+// tally closes the proposal once a majority has voted either way.
+func (c *Council) tally(ctx context.Context, record *ProposalRecord) {
+	yes, no := 0, 0
+	for _, vote := range record.Votes {
+		switch vote.Choice {
+		case VoteYes:
+			yes++
+		case VoteNo:
+			no++
+		}
+	}
+
+	majority := c.AgentCount()/2 + 1
+	switch {
+	case yes >= majority:
+		record.Status = ProposalPassed
+		slog.InfoContext(ctx, "proposal passed", "proposal", record.ID, "yes", yes, "no", no)
+		c.applyActions(ctx, record.Author, record.Actions)
+	case no >= majority:
+		record.Status = ProposalRejected
+		slog.InfoContext(ctx, "proposal rejected", "proposal", record.ID, "yes", yes, "no", no)
+	}
+}
+
+// handleReply records the proposals and votes of an agent's reply and
+// applies its actions.
+func (c *Council) handleReply(ctx context.Context, agent string, reply *AgentReply) {
+	for _, proposal := range reply.Proposals {
+		if _, ok := c.proposals[proposal.ID]; ok {
+			slog.WarnContext(ctx, "ignoring proposal with duplicate id", "agent", agent, "proposal", proposal.ID)
+			continue
+		}
+
+		c.proposals[proposal.ID] = &ProposalRecord{
+			Proposal: proposal,
+			Author:   agent,
+			Status:   ProposalOpen,
+			Votes:    make(map[string]Vote),
+		}
+	}
+
+	for _, vote := range reply.Votes {
+		record, ok := c.proposals[vote.ProposalID]
+		if !ok {
+			slog.WarnContext(ctx, "ignoring vote on unknown proposal", "agent", agent, "proposal", vote.ProposalID)
+			continue
+		}
+		if record.Status != ProposalOpen {
+			continue
+		}
+
+		record.Votes[agent] = vote
+		c.tally(ctx, record)
+	}
+
+	c.applyActions(ctx, agent, reply.Actions)
+}
+
+// Start runs council cycles of observing the world and discussing it until
+// MaxCycles is reached or ctx is done. A cycle interrupted by ctx ends
+// after the reply in progress. It returns the first error of an agent that
+// invalidates the run, see [Agent.Run].
+func (c *Council) Start(ctx context.Context) error {
+	if !c.resumed {
+		c.bus.Publish(ctx, NewMessage(c.opts.RNG, c.world.Now(), "<system>", c.initMessage()))
+	}
+
+	for cycle := 0; c.opts.MaxCycles <= 0 || cycle < c.opts.MaxCycles; cycle++ {
+		if c.opts.Advance != nil {
+			c.opts.Advance(ctx)
+		}
+		if ctx.Err() != nil {
+			return nil
+		}
 
-	for {
 		// Observe the world
 		obs := c.world.Observe(ctx)
 
+		if c.opts.OnCheckpoint != nil && !c.checkpointed && obs.Tick >= c.opts.CheckpointAt {
+			c.checkpointed = true
+			if cp, err := c.Checkpoint(); err != nil {
+				slog.ErrorContext(ctx, "failed to checkpoint simulation", "error", err)
+			} else {
+				c.opts.OnCheckpoint(ctx, cp)
+			}
+		}
+
 		// Agent discussion
 		for range c.opts.MaxRounds {
 			for _, a := range c.agents {
-				a.Run(ctx, &obs)
+				if ctx.Err() != nil {
+					return nil
+				}
+
+				reply, err := a.Run(ctx, c.world, &obs)
+				if err != nil {
+					return fmt.Errorf("agent %s: %w", a.label(), err)
+				}
+				if reply != nil {
+					c.handleReply(ctx, a.ID, reply)
+				}
 			}
 		}
 	}
+	return nil
 }
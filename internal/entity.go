@@ -1,12 +1,8 @@
 package internal
 
 import (
-	"fmt"
 	"log/slog"
-	"math/rand/v2"
 	"reflect"
-
-	"github.com/go-faker/faker/v4"
 )
 
 type EntityID uint
@@ -17,20 +13,11 @@ type Person struct {
 	MoodComponent
 }
 
+// NewPersonEntity generates a person with the default population
+// settings, see [PersonGenerator] to configure them.
 func NewPersonEntity() []Component {
-	return []Component{
-		IdentityComponent{
-			Name: fmt.Sprintf("%s %s", faker.FirstName(), faker.LastName()),
-			Age:  rand.IntN(100),
-		},
-		StatComponent{
-			Health: 100,
-			Money:  rand.IntN(200000),
-		},
-		MoodComponent{
-			Happiness: 100,
-		},
-	}
+	g, _ := NewPersonGenerator(PopulationConfig{}, nil)
+	return g.Next()
 }
 
 func (qr *QueryResult) ToPersons() []Person {
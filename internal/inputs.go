@@ -1,46 +1,300 @@
 package internal
 
-import "sync"
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math"
+	"slices"
+	"sync"
+)
 
 type Action struct {
-	InputName string
-	Value     any
+	InputName string `json:"inputName"`
+	Value     any    `json:"value"`
 }
 
 type Input interface {
 	Name() string
 	Description() string
 	Get() any
-	Set(value any)
+	Set(value any) error
+	Schema() map[string]any // JSON schema of the values accepted by Set
 }
 
-type SimpleInput struct {
+// InputValue is the observable state of an input.
+type InputValue struct {
+	Name        string         `json:"name"`
+	Description string         `json:"description"`
+	Value       any            `json:"value"`
+	Schema      map[string]any `json:"schema"`
+}
+
+func ObserveInput(in Input) InputValue {
+	return InputValue{
+		Name:        in.Name(),
+		Description: in.Description(),
+		Value:       in.Get(),
+		Schema:      in.Schema(),
+	}
+}
+
+type InputKind string
+
+const (
+	InputFloat      InputKind = "float"
+	InputInt        InputKind = "int"
+	InputEnum       InputKind = "enum"
+	InputBool       InputKind = "bool"
+	InputPercentage InputKind = "percentage" // A float between 0 and 100
+)
+
+// InputSpec describes the values a [TypedInput] accepts.
+type InputSpec struct {
+	Kind    InputKind `json:"kind" yaml:"kind"`
+	Min     *float64  `json:"min,omitempty" yaml:"min,omitempty"`         // Inclusive lower bound of numeric kinds
+	Max     *float64  `json:"max,omitempty" yaml:"max,omitempty"`         // Inclusive upper bound of numeric kinds
+	Step    float64   `json:"step,omitempty" yaml:"step,omitempty"`       // Numeric values must be a multiple of Step, any if 0
+	Units   string    `json:"units,omitempty" yaml:"units,omitempty"`     // Units of numeric kinds, e.g. "USD"
+	Options []string  `json:"options,omitempty" yaml:"options,omitempty"` // Allowed values of the enum kind
+	Default any       `json:"default" yaml:"default"`
+}
+
+func (s InputSpec) bounds() (lo, hi float64) {
+	lo, hi = math.Inf(-1), math.Inf(1)
+	if s.Kind == InputPercentage {
+		lo, hi = 0, 100
+	}
+	if s.Min != nil {
+		lo = *s.Min
+	}
+	if s.Max != nil {
+		hi = *s.Max
+	}
+	return lo, hi
+}
+
+func toFloat(value any) (float64, bool) {
+	switch v := value.(type) {
+	case float64:
+		return v, true
+	case float32:
+		return float64(v), true
+	case int:
+		return float64(v), true
+	case int8:
+		return float64(v), true
+	case int16:
+		return float64(v), true
+	case int32:
+		return float64(v), true
+	case int64:
+		return float64(v), true
+	case uint:
+		return float64(v), true
+	case uint8:
+		return float64(v), true
+	case uint16:
+		return float64(v), true
+	case uint32:
+		return float64(v), true
+	case uint64:
+		return float64(v), true
+	case json.Number:
+		f, err := v.Float64()
+		return f, err == nil
+	default:
+		return 0, false
+	}
+}
+
+// isMultiple reports whether f is a whole number of steps from 0.
+func isMultiple(f, step float64) bool {
+	steps := f / step
+	return math.Abs(steps-math.Round(steps)) <= 1e-9
+}
+
+// Normalize validates value against the spec and converts it to the
+// canonical Go type of the kind: float64, int, string or bool.
+func (s InputSpec) Normalize(value any) (any, error) {
+	switch s.Kind {
+	case InputBool:
+		b, ok := value.(bool)
+		if !ok {
+			return nil, fmt.Errorf("expected a boolean, got %T", value)
+		}
+		return b, nil
+
+	case InputEnum:
+		str, ok := value.(string)
+		if !ok {
+			return nil, fmt.Errorf("expected a string, got %T", value)
+		}
+		if !slices.Contains(s.Options, str) {
+			return nil, fmt.Errorf("%q is not one of %v", str, s.Options)
+		}
+		return str, nil
+
+	case InputFloat, InputInt, InputPercentage:
+		f, ok := toFloat(value)
+		if !ok {
+			return nil, fmt.Errorf("expected a number, got %T", value)
+		}
+		if math.IsNaN(f) || math.IsInf(f, 0) {
+			return nil, fmt.Errorf("expected a finite number, got %v", f)
+		}
+
+		lo, hi := s.bounds()
+		if f < lo || f > hi {
+			return nil, fmt.Errorf("%v%s is outside of [%v, %v]", f, s.Units, lo, hi)
+		}
+
+		if s.Step > 0 && !isMultiple(f, s.Step) {
+			return nil, fmt.Errorf("%v is not a multiple of %v", f, s.Step)
+		}
+
+		if s.Kind == InputInt {
+			if f != math.Trunc(f) {
+				return nil, fmt.Errorf("expected an integer, got %v", f)
+			}
+			return int(f), nil
+		}
+		return f, nil
+
+	default:
+		return nil, fmt.Errorf("unknown input kind %q", s.Kind)
+	}
+}
+
+// Validate checks that the spec is consistent and its default is one of
+// the values it accepts.
+func (s InputSpec) Validate() error {
+	switch s.Kind {
+	case InputBool, InputFloat, InputInt, InputPercentage:
+	case InputEnum:
+		if len(s.Options) == 0 {
+			return errors.New("enum inputs require options")
+		}
+	default:
+		return fmt.Errorf("unknown input kind %q", s.Kind)
+	}
+
+	if lo, hi := s.bounds(); lo > hi {
+		return fmt.Errorf("min %v is greater than max %v", lo, hi)
+	}
+	if s.Step < 0 {
+		return fmt.Errorf("step must not be negative, got %v", s.Step)
+	}
+	// Steps are measured from 0, like multipleOf in the schema, so a min
+	// between steps would only leave values the schema rejects
+	if s.Step > 0 && s.Min != nil && !isMultiple(*s.Min, s.Step) {
+		return fmt.Errorf("min %v is not a multiple of step %v", *s.Min, s.Step)
+	}
+
+	if _, err := s.Normalize(s.Default); err != nil {
+		return fmt.Errorf("invalid default: %w", err)
+	}
+	return nil
+}
+
+func (s InputSpec) Schema() map[string]any {
+	schema := map[string]any{}
+	switch s.Kind {
+	case InputBool:
+		schema["type"] = "boolean"
+	case InputEnum:
+		schema["type"] = "string"
+		schema["enum"] = s.Options
+	case InputFloat, InputInt, InputPercentage:
+		schema["type"] = "number"
+		if s.Kind == InputInt {
+			schema["type"] = "integer"
+		}
+
+		lo, hi := s.bounds()
+		if !math.IsInf(lo, -1) {
+			schema["minimum"] = lo
+		}
+		if !math.IsInf(hi, 1) {
+			schema["maximum"] = hi
+		}
+		if s.Step > 0 {
+			schema["multipleOf"] = s.Step
+		}
+		if s.Units != "" {
+			schema["description"] = fmt.Sprintf("Measured in %s.", s.Units)
+		}
+		if s.Kind == InputPercentage && s.Units == "" {
+			schema["description"] = "Measured in percent."
+		}
+	}
+	return schema
+}
+
+// TypedInput is an [Input] that only accepts values matching its spec.
+type TypedInput struct {
 	sync.RWMutex
 
 	name        string
 	description string
+	spec        InputSpec
 	value       any
 }
 
-func NewSimpleInput(name, description string, initial any) *SimpleInput {
-	return &SimpleInput{
+func NewTypedInput(name, description string, spec InputSpec) (*TypedInput, error) {
+	initial, err := spec.Normalize(spec.Default)
+	if err != nil {
+		return nil, fmt.Errorf("invalid default for input %q: %w", name, err)
+	}
+
+	return &TypedInput{
 		name:        name,
 		description: description,
+		spec:        spec,
 		value:       initial,
-	}
+	}, nil
+}
+
+func NewFloatInput(name, description string, min, max, step float64, units string, initial float64) (*TypedInput, error) {
+	return NewTypedInput(name, description, InputSpec{Kind: InputFloat, Min: &min, Max: &max, Step: step, Units: units, Default: initial})
+}
+
+func NewIntInput(name, description string, min, max, step int, units string, initial int) (*TypedInput, error) {
+	lo, hi := float64(min), float64(max)
+	return NewTypedInput(name, description, InputSpec{Kind: InputInt, Min: &lo, Max: &hi, Step: float64(step), Units: units, Default: initial})
+}
+
+func NewPercentageInput(name, description string, initial float64) (*TypedInput, error) {
+	return NewTypedInput(name, description, InputSpec{Kind: InputPercentage, Default: initial})
 }
 
-func (s *SimpleInput) Name() string        { return s.name }
-func (s *SimpleInput) Description() string { return s.description }
+func NewEnumInput(name, description string, options []string, initial string) (*TypedInput, error) {
+	return NewTypedInput(name, description, InputSpec{Kind: InputEnum, Options: options, Default: initial})
+}
 
-func (s *SimpleInput) Get() any {
-	s.RLock()
-	defer s.RUnlock()
-	return s.value
+func NewBoolInput(name, description string, initial bool) (*TypedInput, error) {
+	return NewTypedInput(name, description, InputSpec{Kind: InputBool, Default: initial})
 }
 
-func (s *SimpleInput) Set(value any) {
-	s.Lock()
-	defer s.Unlock()
-	s.value = value
+func (t *TypedInput) Name() string           { return t.name }
+func (t *TypedInput) Description() string    { return t.description }
+func (t *TypedInput) Spec() InputSpec        { return t.spec }
+func (t *TypedInput) Schema() map[string]any { return t.spec.Schema() }
+
+func (t *TypedInput) Get() any {
+	t.RLock()
+	defer t.RUnlock()
+	return t.value
+}
+
+func (t *TypedInput) Set(value any) error {
+	normalized, err := t.spec.Normalize(value)
+	if err != nil {
+		return fmt.Errorf("invalid value for input %q: %w", t.name, err)
+	}
+
+	t.Lock()
+	defer t.Unlock()
+	t.value = normalized
+	return nil
 }
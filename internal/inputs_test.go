@@ -0,0 +1,51 @@
+package internal
+
+import (
+	"math"
+	"testing"
+)
+
+// TestInputSpecStepsMatchSchema checks that every value the schema allows
+// is accepted by Normalize, since tools are generated from the schema.
+func TestInputSpecStepsMatchSchema(t *testing.T) {
+	ptr := func(f float64) *float64 { return &f }
+
+	tests := []struct {
+		name string
+		spec InputSpec
+	}{
+		{"float", InputSpec{Kind: InputFloat, Min: ptr(2), Max: ptr(9), Step: 2, Default: 4.0}},
+		{"fractional", InputSpec{Kind: InputFloat, Min: ptr(0.5), Max: ptr(2), Step: 0.25, Default: 1.0}},
+		{"int", InputSpec{Kind: InputInt, Min: ptr(-10), Max: ptr(10), Step: 5, Default: 0}},
+		{"percentage", InputSpec{Kind: InputPercentage, Step: 2.5, Default: 20.0}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if err := tt.spec.Validate(); err != nil {
+				t.Fatal(err)
+			}
+
+			schema := tt.spec.Schema()
+			lo, hi, step := schema["minimum"].(float64), schema["maximum"].(float64), schema["multipleOf"].(float64)
+			allowed := 0
+			for n := math.Ceil(lo / step); n*step <= hi; n++ {
+				if _, err := tt.spec.Normalize(n * step); err != nil {
+					t.Errorf("schema allows %v, but: %v", n*step, err)
+				}
+				allowed++
+			}
+			if allowed == 0 {
+				t.Error("schema allows no values")
+			}
+		})
+	}
+}
+
+func TestInputSpecRejectsMinBetweenSteps(t *testing.T) {
+	lo, hi := 1.0, 9.0
+	spec := InputSpec{Kind: InputFloat, Min: &lo, Max: &hi, Step: 2, Default: 3.0}
+	if err := spec.Validate(); err == nil {
+		t.Error("accepted min 1 with step 2, leaving no value the schema allows")
+	}
+}
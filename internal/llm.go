@@ -0,0 +1,84 @@
+package internal
+
+import "context"
+
+type ReasoningEffort string
+
+const (
+	ReasoningEffortMinimal ReasoningEffort = "minimal"
+	ReasoningEffortLow     ReasoningEffort = "low"
+	ReasoningEffortMedium  ReasoningEffort = "medium"
+	ReasoningEffortHigh    ReasoningEffort = "high"
+)
+
+type Role string
+
+const (
+	RoleSystem    Role = "system"
+	RoleUser      Role = "user"
+	RoleAssistant Role = "assistant"
+	RoleTool      Role = "tool"
+)
+
+// InputItem is a single provider-agnostic entry in the model input.
+type InputItem struct {
+	Role       Role       `json:"role"`
+	Content    string     `json:"content"`
+	ToolCalls  []ToolCall `json:"toolCalls,omitempty"`  // Tool calls made by the assistant
+	ToolCallID string     `json:"toolCallId,omitempty"` // The tool call a tool item is the result of
+}
+
+// Tool is a function the model may call instead of replying.
+type Tool struct {
+	Name        string         `json:"name"`
+	Description string         `json:"description"`
+	Parameters  map[string]any `json:"parameters"` // JSON schema of the function arguments
+}
+
+type ToolCall struct {
+	ID        string `json:"id"`
+	Name      string `json:"name"`
+	Arguments string `json:"arguments"` // JSON encoded arguments
+}
+
+type Usage struct {
+	InputTokens  int64 `json:"inputTokens"`
+	OutputTokens int64 `json:"outputTokens"`
+	TotalTokens  int64 `json:"totalTokens"`
+}
+
+// JSONSchema constrains the model output to JSON matching Schema.
+type JSONSchema struct {
+	Name        string         `json:"name"`
+	Description string         `json:"description,omitempty"`
+	Schema      map[string]any `json:"schema"`
+}
+
+type GenerateRequest struct {
+	SystemPrompt string          `json:"systemPrompt"`
+	Input        []InputItem     `json:"input"`
+	Reasoning    ReasoningEffort `json:"reasoning,omitempty"`
+	Schema       *JSONSchema     `json:"schema,omitempty"` // Request structured output, free text if nil
+	Tools        []Tool          `json:"tools,omitempty"`
+
+	// Reply is the 1-based number of the agent's reply the request is
+	// for, shared by its tool steps and retries, and 0 for other requests
+	// such as memory summaries. It is not sent to the model.
+	Reply int `json:"-"`
+}
+
+type GenerateResponse struct {
+	Text      string     `json:"text"`
+	ToolCalls []ToolCall `json:"toolCalls,omitempty"`
+	Usage     Usage      `json:"usage"`
+}
+
+// LLMClient is the boundary between an [Agent] and the model backend
+// generating its replies.
+//
+// Implementations adapt a specific provider API, e.g. the OpenAI
+// Responses API, to the request and response types above.
+type LLMClient interface {
+	Model() string
+	Generate(context.Context, GenerateRequest) (GenerateResponse, error)
+}
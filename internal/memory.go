@@ -0,0 +1,215 @@
+package internal
+
+import (
+	"context"
+	"fmt"
+	"slices"
+	"sync"
+)
+
+type MemoryKind string
+
+const (
+	MemoryReceived    MemoryKind = "received"    // A message received from another participant
+	MemoryReply       MemoryKind = "reply"       // A reply sent by the agent itself
+	MemoryObservation MemoryKind = "observation" // An observation of the world state
+)
+
+type MemoryEntry struct {
+	Kind    MemoryKind `json:"kind"`
+	Tick    int64      `json:"tick"`    // The world tick observed when the entry was remembered
+	Message *Message   `json:"message"` // Set for received messages and replies
+	Content string     `json:"content"` // Set for observations
+}
+
+// EstimateTokens approximates the number of tokens in text using the
+// common heuristic of four characters per token.
+func EstimateTokens(text string) int {
+	return (len(text) + 3) / 4
+}
+
+func (e MemoryEntry) tokens() int {
+	if e.Message != nil {
+		return EstimateTokens(e.Message.Contents)
+	}
+	return EstimateTokens(e.Content)
+}
+
+type MemoryOptions struct {
+	Window      int // The maximum number of entries recalled, unlimited if 0
+	TokenBudget int // The maximum estimated tokens recalled, unlimited if 0
+
+	SummarizeThreshold int // The estimated stored tokens above which older entries are summarized, disabled if 0
+	SummarizeKeep      int // The number of most recent entries never summarized
+}
+
+func (o MemoryOptions) Validate() error {
+	for _, opt := range []struct {
+		name  string
+		value int
+	}{
+		{"window", o.Window},
+		{"token budget", o.TokenBudget},
+		{"summarize threshold", o.SummarizeThreshold},
+		{"summarize keep", o.SummarizeKeep},
+	} {
+		if opt.value < 0 {
+			return fmt.Errorf("memory %s must not be negative, got %d", opt.name, opt.value)
+		}
+	}
+	return nil
+}
+
+// Summarizer compresses memory entries into a running summary.
+type Summarizer interface {
+	Summarize(ctx context.Context, summary string, entries []MemoryEntry) (string, error)
+}
+
+// CompactionStats describes the result of a single [Memory.Compact].
+type CompactionStats struct {
+	Entries       int // The number of entries compressed into the summary
+	Tokens        int // The estimated tokens of the compressed entries
+	SummaryTokens int // The estimated tokens of the resulting summary
+}
+
+// Memory is an agent's store of its conversation history and past
+// observations. Older entries may be compressed into a running summary.
+type Memory struct {
+	sync.Mutex
+
+	opts         MemoryOptions
+	summary      string
+	entries      []MemoryEntry
+	lastObserved int64 // The tick of the most recent observation, even if summarized
+}
+
+func NewMemory(opts MemoryOptions) *Memory {
+	return &Memory{opts: opts, lastObserved: -1}
+}
+
+func (m *Memory) Remember(entries ...MemoryEntry) {
+	m.Lock()
+	defer m.Unlock()
+
+	for _, entry := range entries {
+		if entry.Kind == MemoryObservation {
+			m.lastObserved = entry.Tick
+		}
+	}
+	m.entries = append(m.entries, entries...)
+}
+
+func (m *Memory) Summary() string {
+	m.Lock()
+	defer m.Unlock()
+
+	return m.summary
+}
+
+// Compact summarizes all but the most recent entries once the stored
+// history exceeds the summarize threshold. Entries are only dropped once
+// the summarizer succeeds.
+func (m *Memory) Compact(ctx context.Context, summarizer Summarizer) (CompactionStats, error) {
+	keep := max(m.opts.SummarizeKeep, 0)
+
+	m.Lock()
+	if m.opts.SummarizeThreshold <= 0 || len(m.entries) <= keep {
+		m.Unlock()
+		return CompactionStats{}, nil
+	}
+
+	tokens := 0
+	for _, entry := range m.entries {
+		tokens += entry.tokens()
+	}
+	if tokens <= m.opts.SummarizeThreshold {
+		m.Unlock()
+		return CompactionStats{}, nil
+	}
+
+	summary := m.summary
+	older := make([]MemoryEntry, len(m.entries)-keep)
+	copy(older, m.entries)
+	m.Unlock()
+
+	summary, err := summarizer.Summarize(ctx, summary, older)
+	if err != nil {
+		return CompactionStats{}, err
+	}
+
+	m.Lock()
+	defer m.Unlock()
+
+	stats := CompactionStats{Entries: len(older), SummaryTokens: EstimateTokens(summary)}
+	for _, entry := range older {
+		stats.Tokens += entry.tokens()
+	}
+
+	m.summary = summary
+	m.entries = m.entries[len(older):]
+
+	return stats, nil
+}
+
+// LastObservedTick returns the tick of the most recent remembered
+// observation, or -1 if there is none.
+func (m *Memory) LastObservedTick() int64 {
+	m.Lock()
+	defer m.Unlock()
+
+	return m.lastObserved
+}
+
+// Recall returns the most recent entries, oldest first, that fit within
+// the configured window and token budget.
+func (m *Memory) Recall() []MemoryEntry {
+	m.Lock()
+	defer m.Unlock()
+
+	start, tokens := len(m.entries), 0
+	for start > 0 {
+		if m.opts.Window > 0 && len(m.entries)-start >= m.opts.Window {
+			break
+		}
+
+		next := m.entries[start-1].tokens()
+		if m.opts.TokenBudget > 0 && tokens+next > m.opts.TokenBudget {
+			break
+		}
+
+		tokens += next
+		start--
+	}
+
+	recalled := make([]MemoryEntry, len(m.entries)-start)
+	copy(recalled, m.entries[start:])
+	return recalled
+}
+
+// MemoryState is the serializable contents of a [Memory].
+type MemoryState struct {
+	Summary      string        `json:"summary"`
+	Entries      []MemoryEntry `json:"entries"`
+	LastObserved int64         `json:"lastObserved"`
+}
+
+func (m *Memory) State() MemoryState {
+	m.Lock()
+	defer m.Unlock()
+
+	return MemoryState{
+		Summary:      m.summary,
+		Entries:      slices.Clone(m.entries),
+		LastObserved: m.lastObserved,
+	}
+}
+
+// Restore replaces the contents of the memory, keeping its options.
+func (m *Memory) Restore(state MemoryState) {
+	m.Lock()
+	defer m.Unlock()
+
+	m.summary = state.Summary
+	m.entries = slices.Clone(state.Entries)
+	m.lastObserved = state.LastObserved
+}
@@ -3,34 +3,60 @@ package internal
 import (
 	"context"
 	"encoding/json"
+	"slices"
 	"sync"
 	"time"
-
-	"github.com/google/uuid"
 )
 
 type Metadata struct {
-	ID     string `json:"id"`     // Unique identifier of the specific message
-	Sender string `json:"sender"` // The ID of the agent that sent the message
-	SentAt string `json:"sentAt"` // RFC3339 When the message was sent by the agent
+	ID      string `json:"id"`                // Unique identifier of the specific message
+	Sender  string `json:"sender"`            // The ID of the agent that sent the message
+	SentAt  string `json:"sentAt"`            // RFC3339 Simulated time at which the message was sent
+	Thread  string `json:"thread,omitempty"`  // The ID of the message that started the thread
+	ReplyTo string `json:"replyTo,omitempty"` // The ID of the message this message replies to
+
+	AddressedTo []string `json:"addressedTo,omitempty"` // The agents the message is meant for, everyone if empty
 }
 
 type Message struct {
-	Contents string   `json:"contents"` // The actual contents of the message
-	Metadata Metadata `json:"metadata"`
+	Contents  string     `json:"contents"`            // The actual contents of the message
+	Proposals []Proposal `json:"proposals,omitempty"` // Proposals put forward with the message
+	Votes     []Vote     `json:"votes,omitempty"`     // Votes cast with the message
+	Metadata  Metadata   `json:"metadata"`
 }
 
-func NewMessage(sender, contents string) Message {
+// NewMessage builds a message sent at the given simulated time, drawing
+// its ID from rng so seeded runs are reproducible.
+func NewMessage(rng *RNG, sentAt time.Time, sender, contents string) Message {
 	return Message{
 		Contents: contents,
 		Metadata: Metadata{
-			ID:     uuid.NewString(),
+			ID:     rng.UUID(),
 			Sender: sender,
-			SentAt: time.Now().Format(time.RFC3339),
+			SentAt: sentAt.Format(time.RFC3339),
 		},
 	}
 }
 
+// NewReplyMessage builds the message published for a structured agent reply.
+func NewReplyMessage(rng *RNG, sentAt time.Time, sender string, reply *AgentReply) Message {
+	msg := NewMessage(rng, sentAt, sender, reply.Message)
+	msg.Proposals = reply.Proposals
+	msg.Votes = reply.Votes
+	msg.Metadata.AddressedTo = reply.AddressedTo
+	return msg
+}
+
+// InReplyTo marks the message as a reply to parent, continuing its thread.
+func (m Message) InReplyTo(parent Message) Message {
+	m.Metadata.ReplyTo = parent.Metadata.ID
+	m.Metadata.Thread = parent.Metadata.Thread
+	if m.Metadata.Thread == "" {
+		m.Metadata.Thread = parent.Metadata.ID
+	}
+	return m
+}
+
 func (m Message) Bytes() []byte {
 	bs, _ := json.Marshal(m)
 	return bs
@@ -39,9 +65,15 @@ func (m Message) Bytes() []byte {
 type MessageBus interface {
 	// TODO: Consider merging AuditLog with the MessageBus
 	PublishAudit(context.Context, Message) error
-	Publish(context.Context, string, string) error
+	Publish(context.Context, Message) error
 	Subscribe(ctx context.Context, subscriber string)
 	Drain(ctx context.Context, subscriber string) []Message
+
+	// Buffers returns a copy of the undelivered messages per subscriber.
+	Buffers() map[string][]Message
+	// RestoreBuffers replaces the undelivered messages of each current
+	// subscriber present in buffers.
+	RestoreBuffers(buffers map[string][]Message)
 }
 
 type InMemoryBus struct {
@@ -63,12 +95,10 @@ func (b *InMemoryBus) PublishAudit(ctx context.Context, msg Message) error {
 	return nil
 }
 
-func (b *InMemoryBus) Publish(ctx context.Context, from, msg string) error {
+func (b *InMemoryBus) Publish(ctx context.Context, message Message) error {
 	b.Lock()
 	defer b.Unlock()
 
-	message := NewMessage(from, msg)
-
 	b.PublishAudit(ctx, message)
 
 	// NOTE: Consider supporting direct messages between agents.
@@ -76,7 +106,7 @@ func (b *InMemoryBus) Publish(ctx context.Context, from, msg string) error {
 	// agents to communicate "in private"
 
 	for subscriber := range b.buffers {
-		if subscriber == from {
+		if subscriber == message.Metadata.Sender {
 			continue
 		}
 
@@ -118,3 +148,25 @@ func (b *InMemoryBus) Drain(ctx context.Context, subscriber string) []Message {
 
 	return msgs
 }
+
+func (b *InMemoryBus) Buffers() map[string][]Message {
+	b.Lock()
+	defer b.Unlock()
+
+	buffers := make(map[string][]Message, len(b.buffers))
+	for subscriber, msgs := range b.buffers {
+		buffers[subscriber] = slices.Clone(msgs)
+	}
+	return buffers
+}
+
+func (b *InMemoryBus) RestoreBuffers(buffers map[string][]Message) {
+	b.Lock()
+	defer b.Unlock()
+
+	for subscriber := range b.buffers {
+		if msgs, ok := buffers[subscriber]; ok {
+			b.buffers[subscriber] = slices.Clone(msgs)
+		}
+	}
+}
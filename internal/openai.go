@@ -0,0 +1,114 @@
+package internal
+
+import (
+	"context"
+
+	"github.com/openai/openai-go"
+	"github.com/openai/openai-go/option"
+	"github.com/openai/openai-go/responses"
+	"github.com/openai/openai-go/shared"
+)
+
+// OpenAIResponsesClient is an [LLMClient] backed by the OpenAI Responses API.
+type OpenAIResponsesClient struct {
+	client openai.Client
+	model  string
+}
+
+func NewOpenAIResponsesClient(model string, opts ...option.RequestOption) *OpenAIResponsesClient {
+	return &OpenAIResponsesClient{
+		client: openai.NewClient(opts...),
+		model:  model,
+	}
+}
+
+func (c *OpenAIResponsesClient) Model() string { return c.model }
+
+func (c *OpenAIResponsesClient) Generate(ctx context.Context, req GenerateRequest) (GenerateResponse, error) {
+	input := make(responses.ResponseInputParam, 0, len(req.Input))
+	for _, item := range req.Input {
+		if item.Role == RoleTool {
+			input = append(input, responses.ResponseInputItemUnionParam{
+				OfFunctionCallOutput: &responses.ResponseInputItemFunctionCallOutputParam{
+					CallID: item.ToolCallID,
+					Output: item.Content,
+				},
+			})
+			continue
+		}
+
+		if item.Content != "" || len(item.ToolCalls) == 0 {
+			input = append(input, responses.ResponseInputItemUnionParam{
+				OfMessage: &responses.EasyInputMessageParam{
+					Content: responses.EasyInputMessageContentUnionParam{OfString: openai.String(item.Content)},
+					Role:    responses.EasyInputMessageRole(item.Role),
+				},
+			})
+		}
+
+		for _, call := range item.ToolCalls {
+			input = append(input, responses.ResponseInputItemUnionParam{
+				OfFunctionCall: &responses.ResponseFunctionToolCallParam{
+					CallID:    call.ID,
+					Name:      call.Name,
+					Arguments: call.Arguments,
+				},
+			})
+		}
+	}
+
+	params := responses.ResponseNewParams{
+		Model:        c.model,
+		Instructions: openai.String(req.SystemPrompt),
+		Input:        responses.ResponseNewParamsInputUnion{OfInputItemList: input},
+	}
+	if req.Reasoning != "" {
+		params.Reasoning = shared.ReasoningParam{Effort: shared.ReasoningEffort(req.Reasoning)}
+	}
+
+	if req.Schema != nil {
+		params.Text = responses.ResponseTextConfigParam{
+			Format: responses.ResponseFormatTextConfigUnionParam{
+				OfJSONSchema: &responses.ResponseFormatTextJSONSchemaConfigParam{
+					Name:        req.Schema.Name,
+					Description: openai.String(req.Schema.Description),
+					Schema:      req.Schema.Schema,
+					Strict:      openai.Bool(true),
+				},
+			},
+		}
+	}
+
+	for _, tool := range req.Tools {
+		params.Tools = append(params.Tools, responses.ToolUnionParam{
+			OfFunction: &responses.FunctionToolParam{
+				Name:        tool.Name,
+				Description: openai.String(tool.Description),
+				Parameters:  tool.Parameters,
+				Strict:      openai.Bool(true),
+			},
+		})
+	}
+
+	response, err := c.client.Responses.New(ctx, params)
+	if err != nil {
+		return GenerateResponse{}, err
+	}
+
+	var toolCalls []ToolCall
+	for _, item := range response.Output {
+		if item.Type == "function_call" {
+			toolCalls = append(toolCalls, ToolCall{ID: item.CallID, Name: item.Name, Arguments: item.Arguments})
+		}
+	}
+
+	return GenerateResponse{
+		Text:      response.OutputText(),
+		ToolCalls: toolCalls,
+		Usage: Usage{
+			InputTokens:  response.Usage.InputTokens,
+			OutputTokens: response.Usage.OutputTokens,
+			TotalTokens:  response.Usage.TotalTokens,
+		},
+	}, nil
+}
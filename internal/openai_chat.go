@@ -0,0 +1,176 @@
+package internal
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"strings"
+	"sync/atomic"
+
+	"github.com/openai/openai-go"
+	"github.com/openai/openai-go/option"
+	"github.com/openai/openai-go/shared"
+)
+
+// OpenAIChatClient is an [LLMClient] backed by an OpenAI-compatible Chat
+// Completions API, e.g. llama.cpp, vLLM or Ollama.
+//
+// Reasoning effort is sent when requested until the backend rejects it,
+// after which it is dropped for all later requests.
+type OpenAIChatClient struct {
+	client openai.Client
+	model  string
+
+	reasoningUnsupported atomic.Bool
+}
+
+func NewOpenAIChatClient(model, baseURL string, opts ...option.RequestOption) *OpenAIChatClient {
+	if baseURL != "" {
+		opts = append([]option.RequestOption{option.WithBaseURL(baseURL)}, opts...)
+	}
+
+	return &OpenAIChatClient{
+		client: openai.NewClient(opts...),
+		model:  model,
+	}
+}
+
+func (c *OpenAIChatClient) Model() string { return c.model }
+
+// chatMessages maps a request onto chat messages. Many chat templates only
+// accept a single leading system message, so the system prompt and any
+// leading system items are merged and later system items are sent as user
+// messages.
+func chatMessages(req GenerateRequest) []openai.ChatCompletionMessageParamUnion {
+	system := []string{}
+	if req.SystemPrompt != "" {
+		system = append(system, req.SystemPrompt)
+	}
+
+	idx := 0
+	for ; idx < len(req.Input) && req.Input[idx].Role == RoleSystem; idx++ {
+		system = append(system, req.Input[idx].Content)
+	}
+
+	messages := make([]openai.ChatCompletionMessageParamUnion, 0, len(req.Input)+1)
+	if len(system) > 0 {
+		messages = append(messages, openai.SystemMessage(strings.Join(system, "\n")))
+	}
+
+	for _, item := range req.Input[idx:] {
+		switch item.Role {
+		case RoleAssistant:
+			if len(item.ToolCalls) == 0 {
+				messages = append(messages, openai.AssistantMessage(item.Content))
+				continue
+			}
+
+			msg := openai.ChatCompletionAssistantMessageParam{}
+			if item.Content != "" {
+				msg.Content.OfString = openai.String(item.Content)
+			}
+			for _, call := range item.ToolCalls {
+				msg.ToolCalls = append(msg.ToolCalls, openai.ChatCompletionMessageToolCallParam{
+					ID: call.ID,
+					Function: openai.ChatCompletionMessageToolCallFunctionParam{
+						Name:      call.Name,
+						Arguments: call.Arguments,
+					},
+				})
+			}
+			messages = append(messages, openai.ChatCompletionMessageParamUnion{OfAssistant: &msg})
+		case RoleTool:
+			messages = append(messages, openai.ToolMessage(item.Content, item.ToolCallID))
+		default:
+			messages = append(messages, openai.UserMessage(item.Content))
+		}
+	}
+
+	return messages
+}
+
+// isReasoningRejected reports whether err is the backend refusing the
+// reasoning effort parameter.
+func isReasoningRejected(err error) bool {
+	var apiErr *openai.Error
+	if !errors.As(err, &apiErr) {
+		return false
+	}
+
+	if apiErr.StatusCode != http.StatusBadRequest && apiErr.StatusCode != http.StatusUnprocessableEntity {
+		return false
+	}
+
+	return apiErr.Param == "reasoning_effort" || strings.Contains(apiErr.RawJSON(), "reasoning")
+}
+
+func (c *OpenAIChatClient) Generate(ctx context.Context, req GenerateRequest) (GenerateResponse, error) {
+	params := openai.ChatCompletionNewParams{
+		Model:    c.model,
+		Messages: chatMessages(req),
+	}
+
+	if req.Schema != nil {
+		params.ResponseFormat = openai.ChatCompletionNewParamsResponseFormatUnion{
+			OfJSONSchema: &shared.ResponseFormatJSONSchemaParam{
+				JSONSchema: shared.ResponseFormatJSONSchemaJSONSchemaParam{
+					Name:        req.Schema.Name,
+					Description: openai.String(req.Schema.Description),
+					Schema:      req.Schema.Schema,
+					Strict:      openai.Bool(true),
+				},
+			},
+		}
+	}
+
+	for _, tool := range req.Tools {
+		params.Tools = append(params.Tools, openai.ChatCompletionToolParam{
+			Function: shared.FunctionDefinitionParam{
+				Name:        tool.Name,
+				Description: openai.String(tool.Description),
+				Parameters:  shared.FunctionParameters(tool.Parameters),
+				Strict:      openai.Bool(true),
+			},
+		})
+	}
+
+	withReasoning := req.Reasoning != "" && !c.reasoningUnsupported.Load()
+	if withReasoning {
+		params.ReasoningEffort = shared.ReasoningEffort(req.Reasoning)
+	}
+
+	completion, err := c.client.Chat.Completions.New(ctx, params)
+	if err != nil && withReasoning && isReasoningRejected(err) {
+		slog.Warn("backend does not support reasoning effort, retrying without it", "model", c.model)
+		c.reasoningUnsupported.Store(true)
+
+		params.ReasoningEffort = ""
+		completion, err = c.client.Chat.Completions.New(ctx, params)
+	}
+	if err != nil {
+		return GenerateResponse{}, err
+	}
+
+	if len(completion.Choices) == 0 {
+		return GenerateResponse{}, fmt.Errorf("chat completion %s returned no choices", completion.ID)
+	}
+
+	message := completion.Choices[0].Message
+
+	var toolCalls []ToolCall
+	for _, call := range message.ToolCalls {
+		toolCalls = append(toolCalls, ToolCall{ID: call.ID, Name: call.Function.Name, Arguments: call.Function.Arguments})
+	}
+
+	return GenerateResponse{
+		Text:      message.Content,
+		ToolCalls: toolCalls,
+		Usage: Usage{
+			InputTokens:  completion.Usage.PromptTokens,
+			OutputTokens: completion.Usage.CompletionTokens,
+			TotalTokens:  completion.Usage.TotalTokens,
+		},
+	}, nil
+}
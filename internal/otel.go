@@ -31,7 +31,10 @@ var (
 	Tracer = otel.Tracer(serviceName)
 )
 
-// SetupOTelSDK bootstraps the OpenTelemetry pipeline.
+// SetupOTelSDK bootstraps the OpenTelemetry pipeline. Telemetry is optional:
+// the profiler only starts when OTEL_EXPORTER_PYROSCOPE_ENDPOINT is set, and
+// traces, metrics and logs are only exported when an OTLP endpoint is set,
+// otherwise the no-op providers are kept and logs are written to stderr.
 // If it does not return an error, make sure to call shutdown for proper cleanup.
 func SetupOTelSDK(ctx context.Context) (shutdown func(context.Context) error, err error) {
 	var shutdownFuncs []func(context.Context) error
@@ -63,7 +66,14 @@ func SetupOTelSDK(ctx context.Context) (shutdown func(context.Context) error, er
 		handleErr(err)
 		return shutdown, err
 	}
-	shutdownFuncs = append(shutdownFuncs, func(c context.Context) error { return profiler.Stop() })
+	if profiler != nil {
+		shutdownFuncs = append(shutdownFuncs, func(c context.Context) error { return profiler.Stop() })
+	}
+
+	if !otlpConfigured() {
+		slog.SetLogLoggerLevel(getLogLevel())
+		return shutdown, nil
+	}
 
 	// Set up trace provider.
 	tracerProvider, err := newTracerProvider(ctx)
@@ -172,10 +182,22 @@ func newLoggerProvider(ctx context.Context) (*log.LoggerProvider, error) {
 	return loggerProvider, nil
 }
 
+// otlpConfigured reports whether an OTLP endpoint is set for any signal.
+func otlpConfigured() bool {
+	for _, signal := range []string{"", "TRACES_", "METRICS_", "LOGS_"} {
+		if os.Getenv("OTEL_EXPORTER_OTLP_"+signal+"ENDPOINT") != "" {
+			return true
+		}
+	}
+	return false
+}
+
+// startProfiler starts the profiler, or returns nil if no server address
+// is set.
 func startProfiler() (*pyroscope.Profiler, error) {
 	addr := os.Getenv("OTEL_EXPORTER_PYROSCOPE_ENDPOINT")
 	if addr == "" {
-		return nil, errors.New("profiler missing server address")
+		return nil, nil
 	}
 
 	slog.Debug("starting profiler", "serviceName", serviceName)
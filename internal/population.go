@@ -0,0 +1,203 @@
+package internal
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"math/rand/v2"
+
+	"github.com/go-faker/faker/v4"
+)
+
+type DistributionKind string
+
+const (
+	DistributionConstant  DistributionKind = "constant"  // Always Value
+	DistributionUniform   DistributionKind = "uniform"   // Between Min and Max
+	DistributionNormal    DistributionKind = "normal"    // Around Mean with StdDev
+	DistributionLogNormal DistributionKind = "lognormal" // Mu and Sigma of the underlying normal distribution
+	DistributionPareto    DistributionKind = "pareto"    // At least Scale, with a tail heavier the lower Shape is
+)
+
+// Distribution describes how a numeric attribute is spread over a
+// population. Samples of every kind are clamped to Min and Max when set.
+type Distribution struct {
+	Kind   DistributionKind `json:"kind" yaml:"kind"`
+	Value  float64          `json:"value,omitempty" yaml:"value,omitempty"`
+	Min    *float64         `json:"min,omitempty" yaml:"min,omitempty"`
+	Max    *float64         `json:"max,omitempty" yaml:"max,omitempty"`
+	Mean   float64          `json:"mean,omitempty" yaml:"mean,omitempty"`
+	StdDev float64          `json:"stdDev,omitempty" yaml:"stdDev,omitempty"`
+	Mu     float64          `json:"mu,omitempty" yaml:"mu,omitempty"`
+	Sigma  float64          `json:"sigma,omitempty" yaml:"sigma,omitempty"`
+	Scale  float64          `json:"scale,omitempty" yaml:"scale,omitempty"`
+	Shape  float64          `json:"shape,omitempty" yaml:"shape,omitempty"`
+}
+
+func UniformDistribution(lo, hi float64) *Distribution {
+	return &Distribution{Kind: DistributionUniform, Min: &lo, Max: &hi}
+}
+
+func ConstantDistribution(value float64) *Distribution {
+	return &Distribution{Kind: DistributionConstant, Value: value}
+}
+
+func (d Distribution) Validate() error {
+	if d.Min != nil && d.Max != nil && *d.Min > *d.Max {
+		return fmt.Errorf("min %v is greater than max %v", *d.Min, *d.Max)
+	}
+
+	switch d.Kind {
+	case DistributionConstant:
+	case DistributionUniform:
+		if d.Min == nil || d.Max == nil {
+			return errors.New("uniform distributions require min and max")
+		}
+	case DistributionNormal:
+		if d.StdDev < 0 {
+			return fmt.Errorf("stdDev must not be negative, got %v", d.StdDev)
+		}
+	case DistributionLogNormal:
+		if d.Sigma < 0 {
+			return fmt.Errorf("sigma must not be negative, got %v", d.Sigma)
+		}
+	case DistributionPareto:
+		if d.Scale <= 0 || d.Shape <= 0 {
+			return fmt.Errorf("scale and shape must be positive, got %v and %v", d.Scale, d.Shape)
+		}
+	default:
+		return fmt.Errorf("unknown distribution kind %q", d.Kind)
+	}
+	return nil
+}
+
+// Sample draws a value from the distribution, which must be valid.
+func (d Distribution) Sample(rng *rand.Rand) float64 {
+	var value float64
+	switch d.Kind {
+	case DistributionConstant:
+		value = d.Value
+	case DistributionUniform:
+		value = *d.Min + rng.Float64()*(*d.Max-*d.Min)
+	case DistributionNormal:
+		value = d.Mean + rng.NormFloat64()*d.StdDev
+	case DistributionLogNormal:
+		value = math.Exp(d.Mu + rng.NormFloat64()*d.Sigma)
+	case DistributionPareto:
+		value = d.Scale / math.Pow(1-rng.Float64(), 1/d.Shape) // 1-Float64 is in (0, 1]
+	}
+
+	if d.Min != nil {
+		value = max(value, *d.Min)
+	}
+	if d.Max != nil {
+		value = min(value, *d.Max)
+	}
+	return value
+}
+
+// NamesConfig lists the names people are given. People are named by faker
+// when a list is empty.
+type NamesConfig struct {
+	First []string `json:"first,omitempty" yaml:"first,omitempty"`
+	Last  []string `json:"last,omitempty" yaml:"last,omitempty"`
+}
+
+// PersonGenerator generates the people of a population.
+type PersonGenerator struct {
+	population PopulationConfig
+	rng        *RNG
+	count      int // The number of people generated so far
+}
+
+// NewPersonGenerator validates the population config, filling in the
+// defaults of unset fields, and returns a generator drawing each person
+// from their own stream of rng. A randomly seeded rng is used if nil.
+func NewPersonGenerator(population PopulationConfig, rng *RNG) (*PersonGenerator, error) {
+	if population.Size == nil {
+		size := 5
+		population.Size = &size
+	}
+	if *population.Size <= 0 {
+		return nil, fmt.Errorf("invalid population size %d", *population.Size)
+	}
+	if population.Age == nil {
+		population.Age = UniformDistribution(0, 99)
+	}
+	if population.Wealth == nil {
+		population.Wealth = UniformDistribution(0, 199999)
+	}
+	if population.Health == nil {
+		population.Health = ConstantDistribution(100)
+	}
+	if population.Happiness == nil {
+		population.Happiness = ConstantDistribution(100)
+	}
+
+	for name, d := range map[string]*Distribution{
+		"age":       population.Age,
+		"wealth":    population.Wealth,
+		"health":    population.Health,
+		"happiness": population.Happiness,
+	} {
+		if err := d.Validate(); err != nil {
+			return nil, fmt.Errorf("invalid %s distribution: %w", name, err)
+		}
+	}
+
+	if rng == nil {
+		rng = randomRNG()
+	}
+
+	return &PersonGenerator{population: population, rng: rng}, nil
+}
+
+func (g *PersonGenerator) name(rng *RNG) string {
+	first, last := "", ""
+	if names := g.population.Names; names != nil && len(names.First) > 0 {
+		first = names.First[rng.IntN(len(names.First))]
+	} else {
+		first = faker.FirstName()
+	}
+	if names := g.population.Names; names != nil && len(names.Last) > 0 {
+		last = names.Last[rng.IntN(len(names.Last))]
+	} else {
+		last = faker.LastName()
+	}
+	return fmt.Sprintf("%s %s", first, last)
+}
+
+// sampleInt draws a value from the distribution, rounded and clamped to
+// [lo, hi].
+func sampleInt(rng *RNG, d *Distribution, lo, hi float64) int {
+	return int(math.Round(min(max(d.Sample(rng.Rand), lo), hi)))
+}
+
+// Next generates the components of a single person.
+func (g *PersonGenerator) Next() []Component {
+	rng := g.rng.Stream(fmt.Sprintf("person/%d", g.count))
+	g.count++
+
+	return []Component{
+		IdentityComponent{
+			Name: g.name(rng),
+			Age:  sampleInt(rng, g.population.Age, 0, 100),
+		},
+		StatComponent{
+			Health: sampleInt(rng, g.population.Health, 0, 100),
+			Money:  sampleInt(rng, g.population.Wealth, 0, math.MaxInt32),
+		},
+		MoodComponent{
+			Happiness: sampleInt(rng, g.population.Happiness, 0, 100),
+		},
+	}
+}
+
+// Populate registers the configured number of people in the world.
+func (g *PersonGenerator) Populate(w *World) []EntityID {
+	entities := make([]EntityID, 0, *g.population.Size)
+	for range *g.population.Size {
+		entities = append(entities, w.RegisterEntity(g.Next()...))
+	}
+	return entities
+}
@@ -1,7 +1,9 @@
 package internal
 
 import (
+	"encoding/json"
 	"fmt"
+	"html"
 	"strings"
 )
 
@@ -111,6 +113,134 @@ func (p *PromptBuilder) WithParagraph(text string) *PromptBuilder {
 	return p
 }
 
+// WithSummary renders a running summary of earlier history, or a note
+// that there is none yet.
+func (p *PromptBuilder) WithSummary(summary string) *PromptBuilder {
+	if summary == "" {
+		summary = "Nothing has happened yet."
+	}
+	writePrompt(&p.builder, "summary", summary)
+	return p
+}
+
+// traitLevel describes a trait value between 0 and 1 in words.
+func traitLevel(value float64) string {
+	switch {
+	case value < 0.2:
+		return "very low"
+	case value < 0.4:
+		return "low"
+	case value < 0.6:
+		return "moderate"
+	case value < 0.8:
+		return "high"
+	default:
+		return "very high"
+	}
+}
+
+// WithPersona renders the personality of an agent, omitting anything the
+// config leaves unset.
+//
+// Example:
+//
+//	<persona name="..." party="...">
+//	<traits>...</traits>
+//	<tone>...</tone>
+//	<values>...</values>
+//	<beliefs>...</beliefs>
+//	</persona>
+func (p *PromptBuilder) WithPersona(cfg AgentConfig) *PromptBuilder {
+	fmt.Fprintf(&p.builder, "<persona name=\"%s\"", html.EscapeString(cfg.Name))
+	if cfg.Party != "" {
+		fmt.Fprintf(&p.builder, " party=\"%s\"", html.EscapeString(cfg.Party))
+	}
+	p.builder.WriteString(">\n")
+
+	if traits := cfg.Traits.set(); len(traits) > 0 {
+		items := make([]string, 0, len(traits))
+		for _, t := range traits {
+			items = append(items, fmt.Sprintf("%s: %s (%.2f)", t.name, traitLevel(t.value), t.value))
+		}
+		p.builder.WriteString("<traits>\n")
+		WithItems(items...)(&p.builder)
+		p.builder.WriteString("</traits>\n")
+	}
+	if cfg.Tone != "" {
+		writePrompt(&p.builder, "tone", cfg.Tone)
+	}
+	if len(cfg.Values) > 0 {
+		p.builder.WriteString("<values>\n")
+		WithItems(cfg.Values...)(&p.builder)
+		p.builder.WriteString("</values>\n")
+	}
+	if len(cfg.Beliefs) > 0 {
+		p.builder.WriteString("<beliefs>\n")
+		WithItems(cfg.Beliefs...)(&p.builder)
+		p.builder.WriteString("</beliefs>\n")
+	}
+
+	p.builder.WriteString("</persona>\n")
+	return p
+}
+
+// WithMessage renders a single message along with its metadata.
+//
+// Example:
+//
+//	<message id="..." sender="..." sent-at="..." thread="..." reply-to="..." addressed-to="...">
+//	contents
+//	<proposal id="..." title="...">description</proposal>
+//	<vote proposal="..." choice="...">reason</vote>
+//	</message>
+func (p *PromptBuilder) WithMessage(msg Message) *PromptBuilder {
+	fmt.Fprintf(&p.builder, "<message id=\"%s\" sender=\"%s\" sent-at=\"%s\"",
+		html.EscapeString(msg.Metadata.ID),
+		html.EscapeString(msg.Metadata.Sender),
+		html.EscapeString(msg.Metadata.SentAt),
+	)
+	if msg.Metadata.Thread != "" {
+		fmt.Fprintf(&p.builder, " thread=\"%s\"", html.EscapeString(msg.Metadata.Thread))
+	}
+	if msg.Metadata.ReplyTo != "" {
+		fmt.Fprintf(&p.builder, " reply-to=\"%s\"", html.EscapeString(msg.Metadata.ReplyTo))
+	}
+	if len(msg.Metadata.AddressedTo) > 0 {
+		fmt.Fprintf(&p.builder, " addressed-to=\"%s\"", html.EscapeString(strings.Join(msg.Metadata.AddressedTo, ",")))
+	}
+	// Bodies are escaped like attributes, so contents cannot close the
+	// message and pose as another sender
+	fmt.Fprintf(&p.builder, ">\n%s\n", html.EscapeString(msg.Contents))
+	for _, proposal := range msg.Proposals {
+		bs, _ := json.Marshal(proposal.Actions)
+		fmt.Fprintf(&p.builder, "<proposal id=\"%s\" title=\"%s\" actions=\"%s\">%s</proposal>\n",
+			html.EscapeString(proposal.ID),
+			html.EscapeString(proposal.Title),
+			html.EscapeString(string(bs)),
+			html.EscapeString(proposal.Description),
+		)
+	}
+	for _, vote := range msg.Votes {
+		fmt.Fprintf(&p.builder, "<vote proposal=\"%s\" choice=\"%s\">%s</vote>\n",
+			html.EscapeString(vote.ProposalID),
+			html.EscapeString(string(vote.Choice)),
+			html.EscapeString(vote.Reason),
+		)
+	}
+	fmt.Fprintf(&p.builder, "</message>\n")
+	return p
+}
+
+// WithInbox renders messages in the order they were received.
+func (p *PromptBuilder) WithInbox(msgs []Message) *PromptBuilder {
+	fmt.Fprintf(&p.builder, "<inbox count=\"%d\">\n", len(msgs))
+	for _, msg := range msgs {
+		p.WithMessage(msg)
+	}
+	fmt.Fprintf(&p.builder, "</inbox>\n")
+	return p
+}
+
 func (p *PromptBuilder) Build() string {
 	output := p.builder.String()
 	p.builder.Reset()
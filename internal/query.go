@@ -0,0 +1,111 @@
+package internal
+
+import (
+	"iter"
+	"slices"
+	"strings"
+)
+
+// Row2 holds pointers to the components of a single entity matched by
+// [Query2]. Writes through the pointers update the world in place.
+type Row2[A, B any] struct {
+	A *A
+	B *B
+}
+
+// Row3 holds pointers to the components of a single entity matched by
+// [Query3]. Writes through the pointers update the world in place.
+type Row3[A, B, C any] struct {
+	A *A
+	B *B
+	C *C
+}
+
+func componentID[T any]() ComponentID {
+	var zero T
+	return CompReg.GetComponentID(zero)
+}
+
+// matching returns the archetypes containing every component in the
+// signature, ordered by signature so iteration is deterministic.
+func (w *World) matching(signature ArchetypeSignature) []*Archetype {
+	keys := make([]string, 0, len(w.archetypes))
+	for key, arch := range w.archetypes {
+		if arch.HasComponents(signature) {
+			keys = append(keys, key)
+		}
+	}
+	slices.SortFunc(keys, strings.Compare)
+
+	archetypes := make([]*Archetype, 0, len(keys))
+	for _, key := range keys {
+		archetypes = append(archetypes, w.archetypes[key])
+	}
+	return archetypes
+}
+
+func querySignature(ids ...ComponentID) ArchetypeSignature {
+	signature := ArchetypeSignature(slices.Clone(ids))
+	slices.Sort(signature)
+	return slices.Compact(signature)
+}
+
+// Query1 iterates over every entity with a component of type A.
+//
+// Like all queries it does not lock the world, so it must be used from a
+// [System] or within [World.Read].
+//
+// Example:
+//
+//	for id, mood := range Query1[MoodComponent](w) {
+//		mood.Happiness--
+//	}
+func Query1[A any](w *World) iter.Seq2[EntityID, *A] {
+	idA := componentID[A]()
+
+	return func(yield func(EntityID, *A) bool) {
+		for _, arch := range w.matching(querySignature(idA)) {
+			as := arch.Components[idA].([]A)
+			for idx, entity := range arch.Entities {
+				if !yield(entity, &as[idx]) {
+					return
+				}
+			}
+		}
+	}
+}
+
+// Query2 iterates over every entity with components of types A and B.
+func Query2[A, B any](w *World) iter.Seq2[EntityID, Row2[A, B]] {
+	idA, idB := componentID[A](), componentID[B]()
+
+	return func(yield func(EntityID, Row2[A, B]) bool) {
+		for _, arch := range w.matching(querySignature(idA, idB)) {
+			as := arch.Components[idA].([]A)
+			bs := arch.Components[idB].([]B)
+			for idx, entity := range arch.Entities {
+				if !yield(entity, Row2[A, B]{A: &as[idx], B: &bs[idx]}) {
+					return
+				}
+			}
+		}
+	}
+}
+
+// Query3 iterates over every entity with components of types A, B and C.
+func Query3[A, B, C any](w *World) iter.Seq2[EntityID, Row3[A, B, C]] {
+	idA, idB, idC := componentID[A](), componentID[B](), componentID[C]()
+
+	return func(yield func(EntityID, Row3[A, B, C]) bool) {
+		for _, arch := range w.matching(querySignature(idA, idB, idC)) {
+			as := arch.Components[idA].([]A)
+			bs := arch.Components[idB].([]B)
+			cs := arch.Components[idC].([]C)
+			for idx, entity := range arch.Entities {
+				if !yield(entity, Row3[A, B, C]{A: &as[idx], B: &bs[idx], C: &cs[idx]}) {
+					return
+				}
+			}
+		}
+	}
+}
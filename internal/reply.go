@@ -0,0 +1,182 @@
+package internal
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+)
+
+type VoteChoice string
+
+const (
+	VoteYes     VoteChoice = "yes"
+	VoteNo      VoteChoice = "no"
+	VoteAbstain VoteChoice = "abstain"
+)
+
+type Proposal struct {
+	ID          string   `json:"id"`          // Identifier chosen by the proposing agent
+	Title       string   `json:"title"`       // Short name of the proposal
+	Description string   `json:"description"` // What the proposal does and why
+	Actions     []Action `json:"actions"`     // Actions applied to the world if the proposal passes
+}
+
+type Vote struct {
+	ProposalID string     `json:"proposalId"`
+	Choice     VoteChoice `json:"choice"`
+	Reason     string     `json:"reason"`
+}
+
+// AgentReply is the structured output requested from agents.
+type AgentReply struct {
+	Message     string     `json:"message"`     // The message published to the other participants
+	AddressedTo []string   `json:"addressedTo"` // The participants the message is meant for, everyone if empty
+	Proposals   []Proposal `json:"proposals"`   // New proposals put forward to the council
+	Votes       []Vote     `json:"votes"`       // Votes on proposals put forward by any participant
+	Actions     []Action   `json:"actions"`     // Actions the agent applies to the world directly
+}
+
+var actionSchema = map[string]any{
+	"type":                 "object",
+	"additionalProperties": false,
+	"required":             []string{"inputName", "value"},
+	"properties": map[string]any{
+		"inputName": map[string]any{"type": "string", "description": "The name of the world input to set."},
+		"value": map[string]any{
+			"description": "The new value of the input.",
+			"anyOf": []any{
+				map[string]any{"type": "number"},
+				map[string]any{"type": "string"},
+				map[string]any{"type": "boolean"},
+			},
+		},
+	},
+}
+
+// AgentReplySchema is the JSON schema describing an [AgentReply].
+var AgentReplySchema = JSONSchema{
+	Name:        "agent_reply",
+	Description: "A reply to the council along with any proposals, votes and actions.",
+	Schema: map[string]any{
+		"type":                 "object",
+		"additionalProperties": false,
+		"required":             []string{"message", "addressedTo", "proposals", "votes", "actions"},
+		"properties": map[string]any{
+			"message": map[string]any{
+				"type":        "string",
+				"description": "The message sent to the other participants.",
+			},
+			"addressedTo": map[string]any{
+				"type":        "array",
+				"description": "The IDs of the participants the message is meant for. Empty addresses everyone.",
+				"items":       map[string]any{"type": "string"},
+			},
+			"proposals": map[string]any{
+				"type":        "array",
+				"description": "New proposals put forward to the council.",
+				"items": map[string]any{
+					"type":                 "object",
+					"additionalProperties": false,
+					"required":             []string{"id", "title", "description", "actions"},
+					"properties": map[string]any{
+						"id":          map[string]any{"type": "string", "description": "A short unique identifier for the proposal."},
+						"title":       map[string]any{"type": "string"},
+						"description": map[string]any{"type": "string"},
+						"actions":     map[string]any{"type": "array", "items": actionSchema},
+					},
+				},
+			},
+			"votes": map[string]any{
+				"type":        "array",
+				"description": "Votes on open proposals.",
+				"items": map[string]any{
+					"type":                 "object",
+					"additionalProperties": false,
+					"required":             []string{"proposalId", "choice", "reason"},
+					"properties": map[string]any{
+						"proposalId": map[string]any{"type": "string"},
+						"choice":     map[string]any{"type": "string", "enum": []string{string(VoteYes), string(VoteNo), string(VoteAbstain)}},
+						"reason":     map[string]any{"type": "string"},
+					},
+				},
+			},
+			"actions": map[string]any{
+				"type":        "array",
+				"description": "Actions applied to the world immediately.",
+				"items":       actionSchema,
+			},
+		},
+	},
+}
+
+// ParseAgentReply decodes and validates a reply generated against
+// [AgentReplySchema].
+func ParseAgentReply(text string) (*AgentReply, error) {
+	decoder := json.NewDecoder(bytes.NewReader([]byte(text)))
+	decoder.DisallowUnknownFields()
+
+	var reply AgentReply
+	if err := decoder.Decode(&reply); err != nil {
+		return nil, fmt.Errorf("invalid reply: %w", err)
+	}
+
+	if err := reply.Validate(); err != nil {
+		return nil, err
+	}
+
+	return &reply, nil
+}
+
+func validateActions(actions []Action) error {
+	for idx, action := range actions {
+		if action.InputName == "" {
+			return fmt.Errorf("action %d: inputName cannot be empty", idx)
+		}
+		if action.Value == nil {
+			return fmt.Errorf("action %d: value cannot be empty", idx)
+		}
+	}
+	return nil
+}
+
+func (r *AgentReply) Validate() error {
+	var errs []error
+	if r.Message == "" {
+		errs = append(errs, errors.New("message cannot be empty"))
+	}
+
+	proposalIDs := make(map[string]bool)
+	for idx, p := range r.Proposals {
+		if p.ID == "" {
+			errs = append(errs, fmt.Errorf("proposal %d: id cannot be empty", idx))
+		} else if proposalIDs[p.ID] {
+			errs = append(errs, fmt.Errorf("proposal %d: duplicate id %q", idx, p.ID))
+		}
+		proposalIDs[p.ID] = true
+
+		if p.Title == "" {
+			errs = append(errs, fmt.Errorf("proposal %d: title cannot be empty", idx))
+		}
+		if err := validateActions(p.Actions); err != nil {
+			errs = append(errs, fmt.Errorf("proposal %d: %w", idx, err))
+		}
+	}
+
+	for idx, v := range r.Votes {
+		if v.ProposalID == "" {
+			errs = append(errs, fmt.Errorf("vote %d: proposalId cannot be empty", idx))
+		}
+		switch v.Choice {
+		case VoteYes, VoteNo, VoteAbstain:
+		default:
+			errs = append(errs, fmt.Errorf("vote %d: invalid choice %q", idx, v.Choice))
+		}
+	}
+
+	if err := validateActions(r.Actions); err != nil {
+		errs = append(errs, err)
+	}
+
+	return errors.Join(errs...)
+}
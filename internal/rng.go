@@ -0,0 +1,96 @@
+package internal
+
+import (
+	"encoding/binary"
+	"hash/fnv"
+	"math/rand/v2"
+	"sync"
+
+	"github.com/go-faker/faker/v4"
+	"github.com/google/uuid"
+)
+
+// lockedSource guards a PCG so an [RNG] may be shared between goroutines.
+type lockedSource struct {
+	sync.Mutex
+	pcg *rand.PCG
+}
+
+func (s *lockedSource) Uint64() uint64 {
+	s.Lock()
+	defer s.Unlock()
+
+	return s.pcg.Uint64()
+}
+
+// RNG is a deterministic random stream. Streams form a hierarchy: each
+// stream derives independent child streams by name, so the values drawn
+// from one never depend on how much was drawn from another.
+//
+// Example:
+//
+//	root := NewRNG(sim.Seed)
+//	people := root.Stream("population")
+//	alice := people.Stream("person/0")
+type RNG struct {
+	*rand.Rand
+	seed1, seed2 uint64
+}
+
+func NewRNG(seed uint64) *RNG {
+	return newRNG(seed, 0)
+}
+
+func newRNG(seed1, seed2 uint64) *RNG {
+	return &RNG{
+		Rand:  rand.New(&lockedSource{pcg: rand.NewPCG(seed1, seed2)}),
+		seed1: seed1,
+		seed2: seed2,
+	}
+}
+
+// Stream returns the child stream with the given name. It does not draw
+// from r, and the same name always yields the same stream.
+func (r *RNG) Stream(name string) *RNG {
+	h := fnv.New128a()
+	binary.Write(h, binary.LittleEndian, [2]uint64{r.seed1, r.seed2})
+	h.Write([]byte(name))
+
+	sum := h.Sum(nil)
+	return newRNG(binary.LittleEndian.Uint64(sum[:8]), binary.LittleEndian.Uint64(sum[8:]))
+}
+
+// Read fills p with random bytes, making the stream an io.Reader.
+func (r *RNG) Read(p []byte) (int, error) {
+	for i := 0; i < len(p); i += 8 {
+		var word [8]byte
+		binary.LittleEndian.PutUint64(word[:], r.Uint64())
+		copy(p[i:], word[:])
+	}
+	return len(p), nil
+}
+
+// UUID draws a version 4 UUID from the stream.
+func (r *RNG) UUID() string {
+	id, _ := uuid.NewRandomFromReader(r)
+	return id.String()
+}
+
+// fakerSource adapts an [RNG] to the math/rand source used by faker.
+type fakerSource struct{ rng *RNG }
+
+func (s fakerSource) Int63() int64 { return s.rng.Int64() }
+func (s fakerSource) Seed(int64)   {}
+
+// SeedFaker makes faker draw from the stream. Faker's source is global,
+// so values generated by faker are only reproducible if they are
+// generated in a deterministic order.
+func SeedFaker(rng *RNG) {
+	faker.SetRandomSource(fakerSource{rng: rng})
+}
+
+// randomRNG returns a randomly seeded stream for callers that did not
+// provide one.
+func randomRNG() *RNG {
+	return newRNG(rand.Uint64(), rand.Uint64())
+}
@@ -0,0 +1,241 @@
+package internal
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"slices"
+	"sync"
+	"time"
+
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/metric"
+	"go.opentelemetry.io/otel/trace"
+)
+
+var systemDuration, _ = Meter.Float64Histogram(
+	"world.system.duration",
+	metric.WithDescription("The time taken by a system to update the world for one tick"),
+	metric.WithUnit("ms"),
+)
+
+type SystemOptions struct {
+	Priority int  // Systems run in ascending priority, ties run in registration order
+	Disabled bool // Register the system without running it until enabled
+}
+
+type scheduledSystem struct {
+	System
+	opts  SystemOptions
+	order int // Registration order used to break priority ties
+
+	exclusive bool                 // The system did not declare its component access
+	reads     map[ComponentID]bool // Component types read by the system
+	writes    map[ComponentID]bool // Component types written by the system
+}
+
+func newScheduledSystem(sys System, opts SystemOptions, order int) *scheduledSystem {
+	scheduled := &scheduledSystem{
+		System:    sys,
+		opts:      opts,
+		order:     order,
+		exclusive: true,
+		reads:     make(map[ComponentID]bool),
+		writes:    make(map[ComponentID]bool),
+	}
+
+	if access, ok := sys.(ComponentAccess); ok {
+		scheduled.exclusive = false
+		for _, c := range access.Reads() {
+			scheduled.reads[CompReg.GetComponentID(c)] = true
+		}
+		for _, c := range access.Writes() {
+			scheduled.writes[CompReg.GetComponentID(c)] = true
+		}
+	}
+
+	return scheduled
+}
+
+// conflicts reports whether the systems cannot safely run concurrently,
+// i.e. either one writes a component type the other accesses.
+func (s *scheduledSystem) conflicts(other *scheduledSystem) bool {
+	if s.exclusive || other.exclusive {
+		return true
+	}
+
+	for id := range s.writes {
+		if other.reads[id] || other.writes[id] {
+			return true
+		}
+	}
+	for id := range other.writes {
+		if s.reads[id] {
+			return true
+		}
+	}
+	return false
+}
+
+// Scheduler runs the systems registered on a [World] each tick.
+//
+// Enabled systems are grouped into stages in priority order. A system
+// joins the current stage unless it conflicts with a system already in
+// it, in which case it starts the next stage. Systems within a stage run
+// concurrently while stages run one after another, so conflicting systems
+// always observe each other's changes in priority order.
+type Scheduler struct {
+	sync.Mutex
+
+	systems []*scheduledSystem
+	stages  [][]*scheduledSystem
+}
+
+func NewScheduler() *Scheduler {
+	return &Scheduler{}
+}
+
+func (s *Scheduler) Register(sys System, opts SystemOptions) error {
+	s.Lock()
+	defer s.Unlock()
+
+	for _, existing := range s.systems {
+		if existing.Name() == sys.Name() {
+			return fmt.Errorf("system %q is already registered", sys.Name())
+		}
+	}
+
+	scheduled := newScheduledSystem(sys, opts, len(s.systems))
+	for _, existing := range s.systems {
+		if scheduled.conflicts(existing) {
+			slog.Debug("systems conflict and cannot run concurrently", "system", sys.Name(), "conflict", existing.Name())
+		}
+	}
+
+	s.systems = append(s.systems, scheduled)
+	slices.SortStableFunc(s.systems, func(a, b *scheduledSystem) int {
+		if a.opts.Priority != b.opts.Priority {
+			return a.opts.Priority - b.opts.Priority
+		}
+		return a.order - b.order
+	})
+	s.buildStages()
+
+	return nil
+}
+
+// buildStages groups the enabled systems into stages. The caller must
+// hold the lock.
+func (s *Scheduler) buildStages() {
+	stages := [][]*scheduledSystem{}
+	for _, sys := range s.systems {
+		if sys.opts.Disabled {
+			continue
+		}
+
+		if len(stages) > 0 {
+			current := stages[len(stages)-1]
+			if !slices.ContainsFunc(current, sys.conflicts) {
+				stages[len(stages)-1] = append(current, sys)
+				continue
+			}
+		}
+		stages = append(stages, []*scheduledSystem{sys})
+	}
+	s.stages = stages
+}
+
+// Stages returns the names of the enabled systems grouped by the stage
+// they run in.
+func (s *Scheduler) Stages() [][]string {
+	s.Lock()
+	defer s.Unlock()
+
+	stages := make([][]string, 0, len(s.stages))
+	for _, stage := range s.stages {
+		names := make([]string, 0, len(stage))
+		for _, sys := range stage {
+			names = append(names, sys.Name())
+		}
+		stages = append(stages, names)
+	}
+	return stages
+}
+
+// SetEnabled enables or disables the named system from the next tick on.
+func (s *Scheduler) SetEnabled(name string, enabled bool) error {
+	s.Lock()
+	defer s.Unlock()
+
+	for _, sys := range s.systems {
+		if sys.Name() == name {
+			sys.opts.Disabled = !enabled
+			s.buildStages()
+			return nil
+		}
+	}
+
+	return fmt.Errorf("unknown system %q", name)
+}
+
+// Systems returns the names of the enabled systems in the order they run.
+func (s *Scheduler) Systems() []string {
+	s.Lock()
+	defer s.Unlock()
+
+	names := []string{}
+	for _, sys := range s.systems {
+		if !sys.opts.Disabled {
+			names = append(names, sys.Name())
+		}
+	}
+	return names
+}
+
+// Run updates the world with each stage of enabled systems in order.
+func (s *Scheduler) Run(ctx context.Context, w *World, dt time.Duration) {
+	s.Lock()
+	stages := s.stages // Stages are rebuilt rather than modified, so this is safe to use unlocked
+	s.Unlock()
+
+	for _, stage := range stages {
+		buffers := make([]*Commands, len(stage))
+		for idx := range stage {
+			buffers[idx] = NewCommands()
+		}
+
+		if len(stage) == 1 {
+			runSystem(withCommands(ctx, buffers[0]), stage[0], w, dt)
+		} else {
+			var wg sync.WaitGroup
+			for idx, sys := range stage {
+				wg.Go(func() { runSystem(withCommands(ctx, buffers[idx]), sys, w, dt) })
+			}
+			wg.Wait()
+		}
+
+		// Structural changes are applied between stages, in system order
+		for idx, cmds := range buffers {
+			if err := cmds.apply(w); err != nil {
+				slog.WarnContext(ctx, "failed to apply system commands", "system", stage[idx].Name(), "error", err)
+			}
+		}
+	}
+}
+
+func runSystem(ctx context.Context, sys System, w *World, dt time.Duration) {
+	ctx, span := Tracer.Start(ctx, "run system", trace.WithAttributes(
+		attribute.String("system", sys.Name()),
+	))
+	defer span.End()
+
+	ctx = context.WithValue(ctx, rngKey{}, w.systemRNG(sys.Name()))
+
+	start := time.Now()
+	sys.Update(ctx, w, dt)
+
+	systemDuration.Record(ctx,
+		float64(time.Since(start).Microseconds())/1000,
+		metric.WithAttributes(attribute.String("system", sys.Name())),
+	)
+}
@@ -0,0 +1,97 @@
+package internal
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+// ScriptRule is a canned reply returned when all of its non-empty
+// conditions match the request. Rounds count the replies of an agent, so
+// the tool steps and retries of a reply share its round, and requests that
+// are not replies, such as memory summaries, only match rules without one.
+type ScriptRule struct {
+	Agent    string `yaml:"agent,omitempty"`    // Only match requests from this agent.
+	Round    int    `yaml:"round,omitempty"`    // Only match requests for the n-th (1-based) reply of the agent.
+	Contains string `yaml:"contains,omitempty"` // Only match when the input contains this text.
+	Reply    string `yaml:"reply"`              // The reply to generate.
+}
+
+func (r ScriptRule) matches(agent string, round int, input string) bool {
+	if r.Agent != "" && r.Agent != agent {
+		return false
+	}
+	if r.Round != 0 && r.Round != round {
+		return false
+	}
+	if r.Contains != "" && !strings.Contains(input, r.Contains) {
+		return false
+	}
+	return true
+}
+
+// Script describes the replies of a [ScriptedClient]. Rules are evaluated
+// in order and the first match wins, falling back to Default.
+type Script struct {
+	Default string       `yaml:"default"`
+	Rules   []ScriptRule `yaml:"rules"`
+}
+
+func LoadScriptFromFile(filePath string) (*Script, error) {
+	data, err := os.ReadFile(filePath)
+	if err != nil {
+		return nil, err
+	}
+
+	var script Script
+	if err := yaml.Unmarshal(data, &script); err != nil {
+		return nil, fmt.Errorf("cannot load script: %w", err)
+	}
+
+	return &script, nil
+}
+
+func (s *Script) reply(agent string, round int, input string) string {
+	for _, rule := range s.Rules {
+		if rule.matches(agent, round, input) {
+			return rule.Reply
+		}
+	}
+	return s.Default
+}
+
+// ScriptedClient is a deterministic, offline [LLMClient] that generates
+// replies from a [Script] instead of calling a model.
+type ScriptedClient struct {
+	script *Script
+	agent  string // The key used to match rules for the agent using this client
+}
+
+func NewScriptedClient(script *Script, agent string) *ScriptedClient {
+	return &ScriptedClient{script: script, agent: agent}
+}
+
+func (c *ScriptedClient) Model() string { return "scripted" }
+
+func (c *ScriptedClient) Generate(ctx context.Context, req GenerateRequest) (GenerateResponse, error) {
+	var input strings.Builder
+	for _, item := range req.Input {
+		input.WriteString(item.Content)
+		input.WriteRune('\n')
+	}
+
+	reply := c.script.reply(c.agent, req.Reply, input.String())
+
+	// Plain text replies are wrapped so scripts stay readable when
+	// structured output is requested.
+	if req.Schema != nil && !json.Valid([]byte(reply)) {
+		bs, _ := json.Marshal(map[string]string{"message": reply})
+		reply = string(bs)
+	}
+
+	return GenerateResponse{Text: reply}, nil
+}
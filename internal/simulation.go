@@ -2,46 +2,207 @@ package internal
 
 import (
 	"encoding/json"
+	"errors"
 	"fmt"
-	"os"
+	"slices"
 	"strings"
+	"time"
 
-	"github.com/google/uuid"
 	"gopkg.in/yaml.v3"
 )
 
 type PopulationConfig struct {
-	Size int `json:"size" yaml:"size"` // The amount of people in the scenario.
+	Size      *int          `json:"size,omitempty" yaml:"size,omitempty"`           // The amount of people in the scenario, 5 if unset.
+	Age       *Distribution `json:"age,omitempty" yaml:"age,omitempty"`             // Age in years, uniform between 0 and 99 if unset.
+	Wealth    *Distribution `json:"wealth,omitempty" yaml:"wealth,omitempty"`       // Money in dollars, uniform between 0 and 199999 if unset.
+	Health    *Distribution `json:"health,omitempty" yaml:"health,omitempty"`       // Initial health between 0 and 100, 100 if unset.
+	Happiness *Distribution `json:"happiness,omitempty" yaml:"happiness,omitempty"` // Initial happiness between 0 and 100, 100 if unset.
+	Names     *NamesConfig  `json:"names,omitempty" yaml:"names,omitempty"`         // Names people are given, generated if unset.
 }
 
-type Simulation struct {
-	id         string            // Unique simulation ID generated at runtime, used for telemetry correlation.
-	Scenario   string            `json:"scenario" yaml:"scenario"`                         // The scenario in which the agents are participating.
-	Population *PopulationConfig `json:"population,omitempty" yaml:"population,omitempty"` // Details about the population in the scenario.
+// Duration is a time.Duration written as a string such as "1m30s" in
+// config files.
+type Duration time.Duration
+
+func (d Duration) MarshalJSON() ([]byte, error) {
+	return json.Marshal(time.Duration(d).String())
 }
 
-func LoadSimulationFromFile(filePath string) (*Simulation, error) {
-	data, err := os.ReadFile(filePath)
+func (d *Duration) UnmarshalJSON(data []byte) error {
+	var s string
+	if err := json.Unmarshal(data, &s); err != nil {
+		return fmt.Errorf("durations must be strings such as \"1s\": %w", err)
+	}
+	return d.parse(s)
+}
+
+func (d Duration) MarshalYAML() (any, error) {
+	return time.Duration(d).String(), nil
+}
+
+func (d *Duration) UnmarshalYAML(node *yaml.Node) error {
+	return d.parse(node.Value)
+}
+
+func (d *Duration) parse(s string) error {
+	parsed, err := time.ParseDuration(s)
 	if err != nil {
-		return nil, err
+		return err
+	}
+	*d = Duration(parsed)
+	return nil
+}
+
+// InputConfig declares a policy input of the world.
+type InputConfig struct {
+	Name        string           `json:"name" yaml:"name"`
+	Description string           `json:"description,omitempty" yaml:"description,omitempty"`
+	InputSpec   `yaml:",inline"` // The initial value is the spec's default
+}
+
+// SystemConfig enables a system from [SysReg] with its parameters.
+type SystemConfig struct {
+	Name     string         `json:"name" yaml:"name"`
+	Priority int            `json:"priority,omitempty" yaml:"priority,omitempty"`
+	Disabled bool           `json:"disabled,omitempty" yaml:"disabled,omitempty"`
+	Params   map[string]any `json:"params,omitempty" yaml:"params,omitempty"`
+}
+
+type WorldConfig struct {
+	TickDuration Duration       `json:"tickDuration,omitempty" yaml:"tickDuration,omitempty"` // Real time between ticks when the world runs concurrently with the council, 16.667ms if unset.
+	TimePerTick  Duration       `json:"timePerTick,omitempty" yaml:"timePerTick,omitempty"`   // Simulated time advanced by each tick, TickDuration if unset.
+	ObserveEvery int64          `json:"observeEvery,omitempty" yaml:"observeEvery,omitempty"` // Ticks the council advances the world before each observation, the world runs concurrently if 0.
+	Inputs       []InputConfig  `json:"inputs,omitempty" yaml:"inputs,omitempty"`             // Policy inputs the council can set.
+	Systems      []SystemConfig `json:"systems,omitempty" yaml:"systems,omitempty"`           // Systems updating the world each tick, none if unset.
+}
+
+// DefaultTickDuration is the real time between ticks when unset, i.e. 60
+// ticks per second.
+const DefaultTickDuration = 16667 * time.Microsecond
+
+// Tick returns the real and simulated durations of a tick, filling in
+// their defaults.
+func (c WorldConfig) Tick() (real, simulated time.Duration) {
+	real = time.Duration(c.TickDuration)
+	if real <= 0 {
+		real = DefaultTickDuration
+	}
+
+	simulated = time.Duration(c.TimePerTick)
+	if simulated <= 0 {
+		simulated = real
+	}
+	return real, simulated
+}
+
+// Configure registers the inputs and systems of the config on the world.
+func (c WorldConfig) Configure(w *World) error {
+	for _, in := range c.Inputs {
+		input, err := NewTypedInput(in.Name, in.Description, in.InputSpec)
+		if err != nil {
+			return err
+		}
+		w.RegisterInput(input)
+	}
+
+	for _, sc := range c.Systems {
+		sys, err := SysReg.New(sc.Name, sc.Params)
+		if err != nil {
+			return err
+		}
+		if err := w.RegisterSystem(sys, SystemOptions{Priority: sc.Priority, Disabled: sc.Disabled}); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// Traits are Big Five personality traits, each between 0 and 1.
+type Traits struct {
+	Openness          *float64 `json:"openness,omitempty" yaml:"openness,omitempty"`
+	Conscientiousness *float64 `json:"conscientiousness,omitempty" yaml:"conscientiousness,omitempty"`
+	Extraversion      *float64 `json:"extraversion,omitempty" yaml:"extraversion,omitempty"`
+	Agreeableness     *float64 `json:"agreeableness,omitempty" yaml:"agreeableness,omitempty"`
+	Neuroticism       *float64 `json:"neuroticism,omitempty" yaml:"neuroticism,omitempty"`
+}
+
+type trait struct {
+	name  string
+	value float64
+}
+
+// set returns the traits that are set, in a fixed order.
+func (t Traits) set() []trait {
+	traits := []trait{}
+	for name, value := range map[string]*float64{
+		"openness":          t.Openness,
+		"conscientiousness": t.Conscientiousness,
+		"extraversion":      t.Extraversion,
+		"agreeableness":     t.Agreeableness,
+		"neuroticism":       t.Neuroticism,
+	} {
+		if value != nil {
+			traits = append(traits, trait{name: name, value: *value})
+		}
+	}
+	slices.SortFunc(traits, func(a, b trait) int { return strings.Compare(a.name, b.name) })
+	return traits
+}
+
+// LLMProviders are the providers agents can be configured with.
+var LLMProviders = []string{"openai", "chat", "scripted"}
+
+// AgentConfig describes a single council member.
+type AgentConfig struct {
+	Name      string          `json:"name" yaml:"name"`                               // Unique name of the agent, also the key of its rules in LLM scripts.
+	Traits    Traits          `json:"traits,omitempty" yaml:"traits,omitempty"`       // Personality of the agent.
+	Tone      string          `json:"tone,omitempty" yaml:"tone,omitempty"`           // Communication tone and style, e.g. "blunt and folksy".
+	Values    []string        `json:"values,omitempty" yaml:"values,omitempty"`       // Core values the agent upholds.
+	Beliefs   []string        `json:"beliefs,omitempty" yaml:"beliefs,omitempty"`     // Beliefs the agent holds.
+	Party     string          `json:"party,omitempty" yaml:"party,omitempty"`         // Party or ideology of the agent.
+	Provider  string          `json:"provider,omitempty" yaml:"provider,omitempty"`   // LLM provider of the agent, the command line provider if unset.
+	Model     string          `json:"model,omitempty" yaml:"model,omitempty"`         // Model of the agent, the command line model if unset.
+	BaseURL   string          `json:"baseUrl,omitempty" yaml:"baseUrl,omitempty"`     // Base URL of the chat provider, the command line URL if unset.
+	Reasoning ReasoningEffort `json:"reasoning,omitempty" yaml:"reasoning,omitempty"` // Reasoning effort of the agent, medium if unset.
+}
+
+func (c AgentConfig) Validate() error {
+	if c.Name == "" {
+		return errors.New("name cannot be empty")
 	}
 
-	var unmarshaler func([]byte, any) error
-	var sim Simulation
-	if strings.HasSuffix(filePath, ".yaml") || strings.HasSuffix(filePath, ".yml") {
-		unmarshaler = yaml.Unmarshal
-	} else if strings.HasSuffix(filePath, ".json") {
-		unmarshaler = json.Unmarshal
-	} else {
-		return nil, fmt.Errorf("cannot load simulation: unkown format")
+	for _, t := range c.Traits.set() {
+		if t.value < 0 || t.value > 1 {
+			return fmt.Errorf("trait %s must be between 0 and 1, got %v", t.name, t.value)
+		}
 	}
 
-	if err := unmarshaler(data, &sim); err != nil {
-		return nil, err
+	if c.Provider != "" && !slices.Contains(LLMProviders, c.Provider) {
+		return fmt.Errorf("unknown provider %q, expected one of %s", c.Provider, strings.Join(LLMProviders, ", "))
 	}
 
-	sim.id = uuid.NewString()
-	return &sim, nil
+	switch c.Reasoning {
+	case "", ReasoningEffortMinimal, ReasoningEffortLow, ReasoningEffortMedium, ReasoningEffortHigh:
+	default:
+		return fmt.Errorf("unknown reasoning effort %q", c.Reasoning)
+	}
+
+	return nil
+}
+
+type Simulation struct {
+	id         string            // Unique simulation ID generated at runtime, used for telemetry correlation.
+	Scenario   string            `json:"scenario" yaml:"scenario"`                         // The scenario in which the agents are participating.
+	Seed       uint64            `json:"seed,omitempty" yaml:"seed,omitempty"`             // Seeds every random stream of the simulation.
+	Population *PopulationConfig `json:"population,omitempty" yaml:"population,omitempty"` // Details about the population in the scenario.
+	World      WorldConfig       `json:"world,omitempty" yaml:"world,omitempty"`           // Settings of the world the agents govern.
+	Agents     []AgentConfig     `json:"agents,omitempty" yaml:"agents,omitempty"`         // Members of the council.
+	Extends    Paths             `json:"extends,omitempty" yaml:"extends,omitempty"`       // Base configs this one is merged over, relative to its file.
+	Include    Paths             `json:"include,omitempty" yaml:"include,omitempty"`       // Config fragments merged over the bases and beneath this one, relative to its file.
 }
 
 func (s *Simulation) ID() string { return s.id }
+
+// RNG returns the root of the simulation's random streams.
+func (s *Simulation) RNG() *RNG { return NewRNG(s.Seed) }
@@ -0,0 +1,251 @@
+package internal
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"reflect"
+	"slices"
+	"strings"
+	"time"
+)
+
+// SnapshotVersion is the version of the snapshot format written by
+// [World.Snapshot]. Snapshots of other versions are rejected on restore.
+const SnapshotVersion = 1
+
+// ArchetypeSnapshot holds the entities of a single archetype, with the
+// component data stored per registered component name.
+type ArchetypeSnapshot struct {
+	Components []string                   `json:"components"`
+	Entities   []EntityID                 `json:"entities"`
+	Data       map[string]json.RawMessage `json:"data"` // Component name->JSON array ordered as Entities
+}
+
+// WorldSnapshot is the serializable state of a [World]. Registered
+// systems and outputs are not part of the snapshot, and inputs are only
+// captured by value.
+type WorldSnapshot struct {
+	Version      int                 `json:"version"`
+	Tick         int64               `json:"tick"`
+	Clock        time.Duration       `json:"clock"`
+	NextEntityID EntityID            `json:"nextEntityId"`
+	Inputs       map[string]any      `json:"inputs"`
+	Components   map[string]int      `json:"components"` // Component name->Registered version
+	Archetypes   []ArchetypeSnapshot `json:"archetypes"`
+}
+
+// Snapshot captures the state of the world. Every component type in the
+// world must be registered with [ComponentRegistry.Register].
+func (w *World) Snapshot() (*WorldSnapshot, error) {
+	w.registry.RLock()
+	inputs := make(map[string]any, len(w.inputs))
+	for name, in := range w.inputs {
+		inputs[name] = in.Get()
+	}
+	w.registry.RUnlock()
+
+	w.RLock()
+	defer w.RUnlock()
+
+	snapshot := &WorldSnapshot{
+		Version:      SnapshotVersion,
+		Tick:         w.tick,
+		Clock:        w.clock,
+		NextEntityID: w.nextEntityID,
+		Inputs:       inputs,
+		Components:   make(map[string]int),
+		Archetypes:   []ArchetypeSnapshot{},
+	}
+
+	keys := make([]string, 0, len(w.archetypes))
+	for key := range w.archetypes {
+		keys = append(keys, key)
+	}
+	slices.SortFunc(keys, strings.Compare)
+
+	for _, key := range keys {
+		arch := w.archetypes[key]
+		if len(arch.Entities) == 0 {
+			continue
+		}
+
+		archSnapshot := ArchetypeSnapshot{
+			Components: make([]string, 0, len(arch.Signature)),
+			Entities:   slices.Clone(arch.Entities),
+			Data:       make(map[string]json.RawMessage, len(arch.Signature)),
+		}
+
+		for _, id := range arch.Signature {
+			name, ok := CompReg.Name(id)
+			if !ok {
+				return nil, fmt.Errorf("cannot snapshot unregistered component %s", arch.ComponentTypes[id])
+			}
+			info, _ := CompReg.Info(name)
+			snapshot.Components[name] = info.Version
+
+			data, err := json.Marshal(arch.Components[id])
+			if err != nil {
+				return nil, fmt.Errorf("cannot snapshot component %q: %w", name, err)
+			}
+
+			archSnapshot.Components = append(archSnapshot.Components, name)
+			archSnapshot.Data[name] = data
+		}
+
+		snapshot.Archetypes = append(snapshot.Archetypes, archSnapshot)
+	}
+
+	return snapshot, nil
+}
+
+// restoreArchetype rebuilds an archetype from its snapshot. Components
+// whose version differs from the one in versions are rejected, as their
+// data may no longer match their fields.
+func restoreArchetype(snapshot ArchetypeSnapshot, versions map[string]int) (*Archetype, error) {
+	components := make([]Component, 0, len(snapshot.Components))
+	for _, name := range snapshot.Components {
+		info, ok := CompReg.Info(name)
+		if !ok {
+			return nil, fmt.Errorf("unknown component %q", name)
+		}
+		if version, ok := versions[name]; ok && version != info.Version {
+			return nil, fmt.Errorf("component %q has version %d, expected %d", name, version, info.Version)
+		}
+		components = append(components, reflect.New(info.Type).Elem().Interface())
+	}
+
+	arch := NewArchetype(components...)
+	for _, c := range components {
+		id := CompReg.GetComponentID(c)
+		name, _ := CompReg.Name(id)
+
+		slice := reflect.New(reflect.SliceOf(arch.ComponentTypes[id]))
+		if err := json.Unmarshal(snapshot.Data[name], slice.Interface()); err != nil {
+			return nil, fmt.Errorf("cannot restore component %q: %w", name, err)
+		}
+		if slice.Elem().Len() != len(snapshot.Entities) {
+			return nil, fmt.Errorf("component %q has %d values for %d entities", name, slice.Elem().Len(), len(snapshot.Entities))
+		}
+
+		arch.Components[id] = slice.Elem().Interface()
+	}
+
+	for idx, entity := range snapshot.Entities {
+		arch.EntityMap[entity] = idx
+	}
+	arch.Entities = slices.Clone(snapshot.Entities)
+
+	return arch, nil
+}
+
+// Restore replaces the entities, tick and clock of the world with those of
+// the snapshot and sets the value of each registered input it captured.
+func (w *World) Restore(snapshot *WorldSnapshot) error {
+	if snapshot.Version != SnapshotVersion {
+		return fmt.Errorf("unsupported snapshot version %d, expected %d", snapshot.Version, SnapshotVersion)
+	}
+
+	archetypes := make(map[string]*Archetype, len(snapshot.Archetypes))
+	entityIndex := make(map[EntityID]*Archetype)
+	for idx, archSnapshot := range snapshot.Archetypes {
+		arch, err := restoreArchetype(archSnapshot, snapshot.Components)
+		if err != nil {
+			return fmt.Errorf("archetype %d: %w", idx, err)
+		}
+
+		archetypes[arch.Signature.String()] = arch
+		for _, entity := range arch.Entities {
+			entityIndex[entity] = arch
+		}
+	}
+
+	for name, value := range snapshot.Inputs {
+		in, ok := w.GetInput(name)
+		if !ok {
+			return fmt.Errorf("snapshot input %q is not registered", name)
+		}
+		if err := in.Set(value); err != nil {
+			return err
+		}
+	}
+
+	w.Lock()
+	defer w.Unlock()
+
+	w.tick = snapshot.Tick
+	w.clock = snapshot.Clock
+	w.nextEntityID = snapshot.NextEntityID
+	w.archetypes = archetypes
+	w.entityIndex = entityIndex
+
+	return nil
+}
+
+// SaveToFile writes a snapshot of the world to the file, replacing it
+// atomically so a crash mid-write never leaves a corrupt checkpoint.
+func (w *World) SaveToFile(filePath string) error {
+	snapshot, err := w.Snapshot()
+	if err != nil {
+		return err
+	}
+
+	data, err := json.Marshal(snapshot)
+	if err != nil {
+		return err
+	}
+
+	return writeFileAtomic(filePath, data)
+}
+
+// writeFileAtomic writes the data to a temporary file beside the target
+// and renames it into place.
+func writeFileAtomic(filePath string, data []byte) error {
+	tmp, err := os.CreateTemp(filepath.Dir(filePath), filepath.Base(filePath)+".*.tmp")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp.Name())
+
+	// Temporary files are only readable by their owner, unlike those written
+	// with os.WriteFile
+	if err := tmp.Chmod(0o644); err != nil {
+		tmp.Close()
+		return err
+	}
+	if _, err := tmp.Write(data); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		return err
+	}
+
+	return os.Rename(tmp.Name(), filePath)
+}
+
+func ReadSnapshotFromFile(filePath string) (*WorldSnapshot, error) {
+	data, err := os.ReadFile(filePath)
+	if err != nil {
+		return nil, err
+	}
+
+	var snapshot WorldSnapshot
+	if err := json.Unmarshal(data, &snapshot); err != nil {
+		return nil, fmt.Errorf("cannot load snapshot: %w", err)
+	}
+
+	return &snapshot, nil
+}
+
+// LoadFromFile restores the world from a snapshot written by
+// [World.SaveToFile].
+func (w *World) LoadFromFile(filePath string) error {
+	snapshot, err := ReadSnapshotFromFile(filePath)
+	if err != nil {
+		return err
+	}
+
+	return w.Restore(snapshot)
+}
@@ -0,0 +1,47 @@
+package internal
+
+import (
+	"context"
+)
+
+// LLMSummarizer is a [Summarizer] that asks a model to fold memory entries
+// into the running summary.
+type LLMSummarizer struct {
+	llm LLMClient
+}
+
+func NewLLMSummarizer(llm LLMClient) *LLMSummarizer {
+	return &LLMSummarizer{llm: llm}
+}
+
+func (s *LLMSummarizer) Summarize(ctx context.Context, summary string, entries []MemoryEntry) (string, error) {
+	systemPrompt := new(PromptBuilder).
+		WithRole("You maintain the memory of a participant in a simulation with other agents.").
+		Build()
+
+	taskPrompt := new(PromptBuilder).
+		WithTask(
+			"Update the running summary with the history above and reply with only the new summary.",
+			WithItems(
+				"Keep every commitment, proposal, vote and disagreement along with who made it.",
+				"Keep notable changes in the world state.",
+				"Drop small talk and repetition.",
+			),
+		).
+		Build()
+
+	input := []InputItem{{Role: RoleSystem, Content: new(PromptBuilder).WithSummary(summary).Build()}}
+	input = append(input, historyItems(entries)...)
+	input = append(input, InputItem{Role: RoleSystem, Content: taskPrompt})
+
+	response, err := s.llm.Generate(ctx, GenerateRequest{
+		SystemPrompt: systemPrompt,
+		Input:        input,
+		Reasoning:    ReasoningEffortLow,
+	})
+	if err != nil {
+		return "", err
+	}
+
+	return response.Text, nil
+}
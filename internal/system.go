@@ -1,7 +1,14 @@
 package internal
 
 import (
+	"bytes"
 	"context"
+	"encoding/json"
+	"fmt"
+	"math"
+	"slices"
+	"strings"
+	"sync"
 	"time"
 )
 
@@ -9,3 +16,168 @@ type System interface {
 	Name() string
 	Update(context.Context, *World, time.Duration)
 }
+
+// ComponentAccess is implemented by systems that declare the component
+// types they read and write, allowing non-conflicting systems to run
+// concurrently. Systems without a declaration always run alone.
+type ComponentAccess interface {
+	Reads() []Component
+	Writes() []Component
+}
+
+// SystemFactory builds a system from the parameters given in the
+// simulation config, see [DecodeParams].
+type SystemFactory func(params map[string]any) (System, error)
+
+var SysReg = NewSystemRegistry()
+
+func init() {
+	SysReg.MustRegister("aging", NewAgingSystem)
+	SysReg.MustRegister("income", NewIncomeSystem)
+}
+
+// SystemRegistry holds the systems that can be enabled by name from the
+// simulation config.
+type SystemRegistry struct {
+	sync.RWMutex
+
+	factories map[string]SystemFactory
+}
+
+func NewSystemRegistry() *SystemRegistry {
+	return &SystemRegistry{factories: make(map[string]SystemFactory)}
+}
+
+func (r *SystemRegistry) Register(name string, factory SystemFactory) error {
+	r.Lock()
+	defer r.Unlock()
+
+	if _, ok := r.factories[name]; ok {
+		return fmt.Errorf("system %q is already registered", name)
+	}
+	r.factories[name] = factory
+	return nil
+}
+
+func (r *SystemRegistry) MustRegister(name string, factory SystemFactory) {
+	if err := r.Register(name, factory); err != nil {
+		panic(err)
+	}
+}
+
+// New builds the named system with the parameters.
+func (r *SystemRegistry) New(name string, params map[string]any) (System, error) {
+	r.RLock()
+	factory, ok := r.factories[name]
+	r.RUnlock()
+	if !ok {
+		return nil, fmt.Errorf("unknown system %q, expected one of %s", name, strings.Join(r.Names(), ", "))
+	}
+
+	sys, err := factory(params)
+	if err != nil {
+		return nil, fmt.Errorf("invalid params for system %q: %w", name, err)
+	}
+	return sys, nil
+}
+
+// Names returns the names of the registered systems in sorted order.
+func (r *SystemRegistry) Names() []string {
+	r.RLock()
+	defer r.RUnlock()
+
+	names := make([]string, 0, len(r.factories))
+	for name := range r.factories {
+		names = append(names, name)
+	}
+	slices.Sort(names)
+	return names
+}
+
+// DecodeParams decodes system parameters into the fields of v by their
+// json tags, rejecting unknown parameters.
+func DecodeParams(params map[string]any, v any) error {
+	if len(params) == 0 {
+		return nil
+	}
+
+	data, err := json.Marshal(params)
+	if err != nil {
+		return err
+	}
+
+	decoder := json.NewDecoder(bytes.NewReader(data))
+	decoder.DisallowUnknownFields()
+	return decoder.Decode(v)
+}
+
+// Year is the simulated duration of a year used by the built-in systems.
+const Year = 365 * 24 * time.Hour
+
+// stochasticRound rounds x down or up with a probability proportional to
+// its fraction, so small per-tick changes add up to the expected change
+// over many ticks.
+func stochasticRound(rng *RNG, x float64) int {
+	whole, frac := math.Modf(x)
+	if rng.Float64() < math.Abs(frac) {
+		whole += math.Copysign(1, frac)
+	}
+	return int(whole)
+}
+
+// AgingSystem ages people by a year for every simulated year.
+type AgingSystem struct{}
+
+func NewAgingSystem(params map[string]any) (System, error) {
+	if err := DecodeParams(params, &struct{}{}); err != nil {
+		return nil, err
+	}
+	return &AgingSystem{}, nil
+}
+
+func (s *AgingSystem) Name() string        { return "aging" }
+func (s *AgingSystem) Reads() []Component  { return nil }
+func (s *AgingSystem) Writes() []Component { return []Component{IdentityComponent{}} }
+
+func (s *AgingSystem) Update(ctx context.Context, w *World, dt time.Duration) {
+	years := float64(dt) / float64(Year)
+	for entity, identity := range Query1[IdentityComponent](w) {
+		identity.Age = min(identity.Age+stochasticRound(w.EntityRNG(ctx, entity), years), 100)
+	}
+}
+
+// IncomeSystem pays people an annual income, less the income tax set by
+// the council through a percentage input.
+type IncomeSystem struct {
+	AnnualIncome float64 `json:"annualIncome"` // The income of every person per simulated year before tax
+	TaxInput     string  `json:"taxInput"`     // The name of the input holding the income tax percentage, untaxed if unregistered
+}
+
+func NewIncomeSystem(params map[string]any) (System, error) {
+	s := &IncomeSystem{AnnualIncome: 50000, TaxInput: "income_tax"}
+	if err := DecodeParams(params, s); err != nil {
+		return nil, err
+	}
+	if s.AnnualIncome < 0 {
+		return nil, fmt.Errorf("annualIncome must not be negative, got %v", s.AnnualIncome)
+	}
+	return s, nil
+}
+
+func (s *IncomeSystem) Name() string        { return "income" }
+func (s *IncomeSystem) Reads() []Component  { return nil }
+func (s *IncomeSystem) Writes() []Component { return []Component{StatComponent{}} }
+
+func (s *IncomeSystem) Update(ctx context.Context, w *World, dt time.Duration) {
+	tax := 0.0
+	if in, ok := w.GetInput(s.TaxInput); ok {
+		if rate, ok := toFloat(in.Get()); ok {
+			tax = min(max(rate, 0), 100) / 100
+		}
+	}
+
+	income := s.AnnualIncome * (1 - tax) * float64(dt) / float64(Year)
+	for entity, stats := range Query1[StatComponent](w) {
+		stats.Money += stochasticRound(w.EntityRNG(ctx, entity), income)
+	}
+}
@@ -0,0 +1,82 @@
+package internal
+
+import (
+	"encoding/json"
+	"fmt"
+	"regexp"
+	"slices"
+	"strings"
+)
+
+const inputToolPrefix = "set_"
+
+var invalidToolName = regexp.MustCompile(`[^a-zA-Z0-9_-]`)
+
+// InputToolName returns the name of the tool that sets the named input.
+func InputToolName(input string) string {
+	return inputToolPrefix + invalidToolName.ReplaceAllString(input, "_")
+}
+
+func inputTool(in Input) Tool {
+	schema := in.Schema()
+	if _, ok := schema["description"]; !ok {
+		schema["description"] = "The new value of the input."
+	}
+
+	return Tool{
+		Name:        InputToolName(in.Name()),
+		Description: fmt.Sprintf("Set the %q policy input. %s", in.Name(), in.Description()),
+		Parameters: map[string]any{
+			"type":                 "object",
+			"additionalProperties": false,
+			"required":             []string{"value"},
+			"properties":           map[string]any{"value": schema},
+		},
+	}
+}
+
+// Tools returns a tool for setting each registered input, sorted by name.
+func (w *World) Tools() []Tool {
+	w.registry.RLock()
+	defer w.registry.RUnlock()
+
+	tools := make([]Tool, 0, len(w.inputs))
+	for _, in := range w.inputs {
+		tools = append(tools, inputTool(in))
+	}
+	slices.SortFunc(tools, func(a, b Tool) int { return strings.Compare(a.Name, b.Name) })
+
+	return tools
+}
+
+// ActionFromToolCall resolves a call of one of the world's tools to the
+// action it requests. Calls of a tool shared by several inputs, whose names
+// only differ in characters invalid in tool names, are rejected.
+func (w *World) ActionFromToolCall(call ToolCall) (Action, error) {
+	w.registry.RLock()
+	defer w.registry.RUnlock()
+
+	var names []string
+	for name := range w.inputs {
+		if InputToolName(name) == call.Name {
+			names = append(names, name)
+		}
+	}
+	switch len(names) {
+	case 0:
+		return Action{}, fmt.Errorf("unknown tool %q", call.Name)
+	case 1:
+	default:
+		slices.Sort(names)
+		return Action{}, fmt.Errorf("tool %q is ambiguous between inputs %v", call.Name, names)
+	}
+
+	var args struct {
+		Value any `json:"value"`
+	}
+	if err := json.Unmarshal([]byte(call.Arguments), &args); err != nil {
+		return Action{}, fmt.Errorf("invalid arguments for %s: %w", call.Name, err)
+	}
+
+	return Action{InputName: names[0], Value: args.Value}, nil
+}
@@ -3,15 +3,22 @@ package internal
 import (
 	"context"
 	"encoding/json"
+	"fmt"
+	"log/slog"
+	"maps"
+	"slices"
 	"sync"
 	"time"
+
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
 )
 
 type Observation struct {
 	Tick      int64                  `json:"tick"`
 	Timestamp int64                  `json:"timestamp"`
 	People    []Person               `json:"people"`
-	Inputs    map[string]any         `json:"inputs"`
+	Inputs    map[string]InputValue  `json:"inputs"`
 	Outputs   map[string]OutputValue `json:"outputs"`
 }
 
@@ -20,20 +27,55 @@ func (o *Observation) ToJSON() string {
 	return string(bs)
 }
 
+// SummaryJSON renders the observation without its people, only counting
+// them, and with the values of its inputs and outputs alone. Its size does
+// not grow with the population, so it can be kept in memory every cycle.
+func (o *Observation) SummaryJSON() string {
+	inputs := make(map[string]any, len(o.Inputs))
+	for name, in := range o.Inputs {
+		inputs[name] = in.Value
+	}
+	outputs := make(map[string]any, len(o.Outputs))
+	for name, out := range o.Outputs {
+		outputs[name] = out.Value
+	}
+
+	bs, _ := json.Marshal(struct {
+		Tick       int64          `json:"tick"`
+		Timestamp  int64          `json:"timestamp"`
+		Population int            `json:"population"`
+		Inputs     map[string]any `json:"inputs"`
+		Outputs    map[string]any `json:"outputs"`
+	}{o.Tick, o.Timestamp, len(o.People), inputs, outputs})
+	return string(bs)
+}
+
 type QueryResult struct {
 	Archetype  *Archetype `json:"-"`
 	Components []any      `json:"components"`
 	Count      int        `json:"count"`
 }
 
+// World holds the simulation state.
+//
+// The embedded lock guards entity storage, the tick and the clock. Tick
+// holds the write lock for its whole duration, so systems run exclusively
+// and observations, queries and structural changes made elsewhere happen
+// between ticks. Systems must therefore only use the lock-free queries
+// and queue structural changes with [World.Commands]. Registered inputs,
+// outputs and systems have their own locks and may be used at any time.
 type World struct {
 	sync.RWMutex
 
 	clock time.Duration
 	tick  int64
 
-	inputs  map[string]Input
-	outputs map[string]Output
+	registry  sync.RWMutex // Guards inputs and outputs
+	inputs    map[string]Input
+	outputs   map[string]Output
+	scheduler *Scheduler
+	commands  *Commands
+	rng       *RNG
 
 	nextEntityID EntityID
 	archetypes   map[string]*Archetype
@@ -44,48 +86,178 @@ func NewWorld() *World {
 	return &World{
 		inputs:       make(map[string]Input),
 		outputs:      make(map[string]Output),
+		scheduler:    NewScheduler(),
+		commands:     NewCommands(),
+		rng:          randomRNG(),
 		nextEntityID: 0,
 		archetypes:   make(map[string]*Archetype),
 		entityIndex:  make(map[EntityID]*Archetype),
 	}
 }
 
-func (w *World) RegisterInput(in Input) *World {
+// WithRNG seeds the random streams of systems and entities from rng, which
+// are otherwise seeded randomly.
+func (w *World) WithRNG(rng *RNG) *World {
 	w.Lock()
 	defer w.Unlock()
 
-	w.inputs[in.Name()] = in
+	w.rng = rng
 	return w
 }
 
-func (w *World) GetInput(name string) (Input, bool) {
+// RNG returns the random stream to draw from.
+//
+// Within a system's Update this is the system's own stream for the
+// current tick, so draws are reproducible regardless of how systems are
+// scheduled. Elsewhere it is the world's stream, which takes the read
+// lock, so it must not be called while holding the lock outside a system.
+func (w *World) RNG(ctx context.Context) *RNG {
+	if rng, ok := ctx.Value(rngKey{}).(*RNG); ok {
+		return rng
+	}
+
+	w.RLock()
+	defer w.RUnlock()
+
+	return w.rng
+}
+
+// EntityRNG returns the random stream of the entity, derived from the
+// system's stream within a system's Update and from the world's stream for
+// the current tick elsewhere. Every call returns a new stream with the
+// same values, so it should be kept rather than requested per draw.
+// Within a system it does not lock the world; elsewhere it takes the read
+// lock, so it must not be called while holding the lock.
+func (w *World) EntityRNG(ctx context.Context, entity EntityID) *RNG {
+	if rng, ok := ctx.Value(rngKey{}).(*RNG); ok {
+		return rng.Stream(fmt.Sprintf("entity/%d", entity))
+	}
+
 	w.RLock()
 	defer w.RUnlock()
 
+	return w.rng.Stream(fmt.Sprintf("entity/%d/tick/%d", entity, w.tick))
+}
+
+type rngKey struct{}
+
+// systemRNG returns the stream of the system for the current tick. The
+// caller must hold the lock.
+func (w *World) systemRNG(name string) *RNG {
+	return w.rng.Stream(fmt.Sprintf("system/%s/tick/%d", name, w.tick))
+}
+
+func (w *World) RegisterInput(in Input) *World {
+	w.registry.Lock()
+	defer w.registry.Unlock()
+
+	w.inputs[in.Name()] = in
+	return w
+}
+
+func (w *World) GetInput(name string) (Input, bool) {
+	w.registry.RLock()
+	defer w.registry.RUnlock()
+
 	in, ok := w.inputs[name]
 	return in, ok
 }
 
+// ApplyAction sets the input named by the action to its value.
+func (w *World) ApplyAction(action Action) error {
+	in, ok := w.GetInput(action.InputName)
+	if !ok {
+		return fmt.Errorf("unknown input %q", action.InputName)
+	}
+
+	return in.Set(action.Value)
+}
+
 func (w *World) RegisterOutput(out Output) *World {
-	w.Lock()
-	defer w.Unlock()
+	w.registry.Lock()
+	defer w.registry.Unlock()
 
 	w.outputs[out.Name()] = out
 	return w
 }
 
 func (w *World) GetOutput(name string) (Output, bool) {
-	w.RLock()
-	defer w.RUnlock()
+	w.registry.RLock()
+	defer w.registry.RUnlock()
 
 	out, ok := w.outputs[name]
 	return out, ok
 }
 
+// RegisterSystem schedules the system to update the world each tick.
+func (w *World) RegisterSystem(sys System, opts SystemOptions) error {
+	return w.scheduler.Register(sys, opts)
+}
+
+func (w *World) EnableSystem(name string) error  { return w.scheduler.SetEnabled(name, true) }
+func (w *World) DisableSystem(name string) error { return w.scheduler.SetEnabled(name, false) }
+
+// Systems returns the names of the enabled systems in the order they run.
+func (w *World) Systems() []string { return w.scheduler.Systems() }
+
+// SystemStages returns the names of the enabled systems grouped by the
+// stage they run in. Systems in the same stage run concurrently.
+func (w *World) SystemStages() [][]string { return w.scheduler.Stages() }
+
+// CurrentTick returns the number of ticks processed so far.
+func (w *World) CurrentTick() int64 {
+	w.RLock()
+	defer w.RUnlock()
+
+	return w.tick
+}
+
+// Clock returns the total simulated time processed so far.
+func (w *World) Clock() time.Duration {
+	w.RLock()
+	defer w.RUnlock()
+
+	return w.clock
+}
+
+// WorldEpoch is the simulated time at which every world starts.
+var WorldEpoch = time.Date(2000, time.January, 1, 0, 0, 0, 0, time.UTC)
+
+// Now returns the simulated time of the world, i.e. [WorldEpoch]
+// advanced by its clock.
+func (w *World) Now() time.Time {
+	return WorldEpoch.Add(w.Clock())
+}
+
+// Read calls fn while holding the read lock, during which the lock-free
+// queries are safe to use outside of systems.
+func (w *World) Read(fn func(*World)) {
+	w.RLock()
+	defer w.RUnlock()
+
+	fn(w)
+}
+
+// RegisterEntity adds an entity to the world. It must not be called from
+// a system, which should use [Commands.Spawn] instead.
 func (w *World) RegisterEntity(components ...Component) EntityID {
+	w.Lock()
+	defer w.Unlock()
+
+	return w.registerEntity(components...)
+}
+
+func (w *World) registerEntity(components ...Component) EntityID {
 	entity := w.nextEntityID
 	w.nextEntityID++
 
+	w.place(entity, components...)
+
+	return entity
+}
+
+// place adds the entity to the archetype matching its components.
+func (w *World) place(entity EntityID, components ...Component) {
 	signature := MakeSignature(components...)
 	signKey := signature.String()
 
@@ -97,10 +269,91 @@ func (w *World) RegisterEntity(components ...Component) EntityID {
 
 	arch.AddEntity(entity, components...)
 	w.entityIndex[entity] = arch
+}
 
-	return entity
+// Despawn removes the entity and all of its components from the world.
+// It must not be called from a system, which should use
+// [Commands.Despawn] instead.
+func (w *World) Despawn(entity EntityID) error {
+	w.Lock()
+	defer w.Unlock()
+
+	return w.despawn(entity)
+}
+
+func (w *World) despawn(entity EntityID) error {
+	arch, ok := w.entityIndex[entity]
+	if !ok {
+		return fmt.Errorf("unknown entity %d", entity)
+	}
+
+	arch.RemoveEntity(entity)
+	delete(w.entityIndex, entity)
+
+	return nil
+}
+
+// AddComponent attaches the component to the entity, moving it to the
+// archetype of its new component set. A component of a type the entity
+// already has is overwritten in place. It must not be called from a
+// system, which should use [Commands.AddComponent] instead.
+func (w *World) AddComponent(entity EntityID, c Component) error {
+	w.Lock()
+	defer w.Unlock()
+
+	return w.addComponent(entity, c)
+}
+
+func (w *World) addComponent(entity EntityID, c Component) error {
+	arch, ok := w.entityIndex[entity]
+	if !ok {
+		return fmt.Errorf("unknown entity %d", entity)
+	}
+
+	if arch.SetComponent(entity, c) {
+		return nil
+	}
+
+	components := append(arch.EntityComponents(entity), c)
+	arch.RemoveEntity(entity)
+	w.place(entity, components...)
+
+	return nil
+}
+
+// RemoveComponent detaches the component of the same type as c from the
+// entity, moving it to the archetype of its remaining component set. It
+// must not be called from a system, which should use
+// [Commands.RemoveComponent] instead.
+func (w *World) RemoveComponent(entity EntityID, c Component) error {
+	w.Lock()
+	defer w.Unlock()
+
+	return w.removeComponent(entity, c)
+}
+
+func (w *World) removeComponent(entity EntityID, c Component) error {
+	arch, ok := w.entityIndex[entity]
+	if !ok {
+		return fmt.Errorf("unknown entity %d", entity)
+	}
+
+	id := CompReg.GetComponentID(c)
+	if _, ok := arch.Components[id]; !ok {
+		return fmt.Errorf("entity %d has no %T component", entity, c)
+	}
+
+	components := slices.DeleteFunc(arch.EntityComponents(entity), func(existing Component) bool {
+		return CompReg.GetComponentID(existing) == id
+	})
+	arch.RemoveEntity(entity)
+	w.place(entity, components...)
+
+	return nil
 }
 
+// Query returns the component slices of every archetype matching the
+// components. It does not lock the world, see [World.Read].
 func (w *World) Query(components ...Component) []QueryResult {
 	querySignature := MakeSignature(components...)
 
@@ -135,36 +388,55 @@ func (w *World) Query(components ...Component) []QueryResult {
 //
 // ```
 func (w *World) Tick(ctx context.Context, dt time.Duration) {
+	w.Lock()
+	defer w.Unlock()
+
+	ctx, span := Tracer.Start(ctx, "tick world", trace.WithAttributes(
+		attribute.Int64("tick", w.tick+1),
+	))
+	defer span.End()
+
 	w.tick++
 	w.clock += dt
 
-	// For each system
-	// 	Execute system
+	w.scheduler.Run(ctx, w, dt)
+
+	if err := w.commands.apply(w); err != nil {
+		slog.WarnContext(ctx, "failed to apply world commands", "error", err)
+	}
 }
 
 func (w *World) Observe(ctx context.Context) Observation {
+	w.registry.RLock()
+	registeredInputs := maps.Clone(w.inputs)
+	registeredOutputs := maps.Clone(w.outputs)
+	w.registry.RUnlock()
+
 	w.RLock()
 	defer w.RUnlock()
 
-	inputs := make(map[string]any)
-	for name, in := range w.inputs {
-		inputs[name] = in.Get()
+	inputs := make(map[string]InputValue)
+	for name, in := range registeredInputs {
+		inputs[name] = ObserveInput(in)
 	}
 
 	outputs := make(map[string]OutputValue)
-	for name, out := range w.outputs {
+	for name, out := range registeredOutputs {
 		outputs[name] = out.Compute(ctx, w)
 	}
 
-	persons := w.Query([]Component{
-		IdentityComponent{},
-		StatComponent{},
-		MoodComponent{},
-	}...)[0].ToPersons()
+	persons := []Person{}
+	for _, row := range Query3[IdentityComponent, StatComponent, MoodComponent](w) {
+		persons = append(persons, Person{
+			IdentityComponent: *row.A,
+			StatComponent:     *row.B,
+			MoodComponent:     *row.C,
+		})
+	}
 
 	return Observation{
 		Tick:      w.tick,
-		Timestamp: time.Now().UnixMilli(),
+		Timestamp: WorldEpoch.Add(w.clock).UnixMilli(),
 		People:    persons,
 		Inputs:    inputs,
 		Outputs:   outputs,
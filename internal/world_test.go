@@ -0,0 +1,144 @@
+package internal
+
+import (
+	"context"
+	"sync"
+	"testing"
+	"time"
+)
+
+// newTestWorld returns a seeded world with a population, an income tax
+// input and the built-in systems.
+func newTestWorld(t *testing.T) *World {
+	t.Helper()
+
+	w := NewWorld().WithRNG(NewRNG(1)).RegisterOutput(new(ApprovalMetric))
+
+	tax, err := NewTypedInput("income_tax", "", InputSpec{Kind: InputPercentage, Default: 20})
+	if err != nil {
+		t.Fatal(err)
+	}
+	w.RegisterInput(tax)
+
+	for _, name := range []string{"aging", "income"} {
+		sys, err := SysReg.New(name, nil)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if err := w.RegisterSystem(sys, SystemOptions{}); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	size := 50
+	people, err := NewPersonGenerator(PopulationConfig{
+		Size:  &size,
+		Names: &NamesConfig{First: []string{"Ada", "Bram"}, Last: []string{"Evans", "Price"}},
+	}, NewRNG(2))
+	if err != nil {
+		t.Fatal(err)
+	}
+	people.Populate(w)
+	return w
+}
+
+// TestWorldConcurrentAccess ticks the world while every method meant to be
+// used outside of systems is called concurrently. It is only meaningful
+// with -race.
+func TestWorldConcurrentAccess(t *testing.T) {
+	w := newTestWorld(t)
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	var ticking sync.WaitGroup
+	ticking.Go(func() {
+		for range 50 {
+			w.Tick(ctx, 24*time.Hour)
+		}
+		cancel()
+	})
+
+	callers := map[string]func(i int){
+		"Observe": func(int) { w.Observe(ctx) },
+		"Snapshot": func(int) {
+			if _, err := w.Snapshot(); err != nil {
+				t.Error(err)
+			}
+		},
+		"Tools": func(int) { w.Tools() },
+		"ApplyAction": func(i int) {
+			if err := w.ApplyAction(Action{InputName: "income_tax", Value: float64(i % 100)}); err != nil {
+				t.Error(err)
+			}
+		},
+		"RegisterEntity": func(i int) {
+			w.RegisterEntity(IdentityComponent{Name: "Newcomer", Age: i % 100}, StatComponent{Health: 100})
+		},
+		"AddComponentDespawn": func(int) {
+			entity := w.RegisterEntity(IdentityComponent{Name: "Visitor"})
+			if err := w.AddComponent(entity, MoodComponent{Happiness: 50}); err != nil {
+				t.Error(err)
+			}
+			if err := w.Despawn(entity); err != nil {
+				t.Error(err)
+			}
+		},
+		"RNG": func(i int) {
+			w.RNG(ctx).IntN(10)
+			w.EntityRNG(ctx, EntityID(i%50)).IntN(10)
+		},
+		"Clock": func(int) {
+			w.CurrentTick()
+			w.Now()
+		},
+	}
+
+	var calling sync.WaitGroup
+	for name, call := range callers {
+		calling.Go(func() {
+			for i := 0; ctx.Err() == nil; i++ {
+				call(i)
+			}
+			t.Logf("%s called concurrently with Tick", name)
+		})
+	}
+
+	ticking.Wait()
+	calling.Wait()
+
+	if tick := w.CurrentTick(); tick != 50 {
+		t.Errorf("got tick %d, want 50", tick)
+	}
+}
+
+// TestWorldTickDeterministic checks that seeded worlds evolve identically,
+// however their systems are scheduled.
+func TestWorldTickDeterministic(t *testing.T) {
+	ctx := context.Background()
+
+	var snapshots []*WorldSnapshot
+	for range 2 {
+		w := newTestWorld(t)
+		for range 100 {
+			w.Tick(ctx, 24*time.Hour)
+		}
+
+		snapshot, err := w.Snapshot()
+		if err != nil {
+			t.Fatal(err)
+		}
+		snapshots = append(snapshots, snapshot)
+	}
+
+	a, b := snapshots[0], snapshots[1]
+	if len(a.Archetypes) != len(b.Archetypes) {
+		t.Fatalf("got %d and %d archetypes", len(a.Archetypes), len(b.Archetypes))
+	}
+	for i := range a.Archetypes {
+		for name, data := range a.Archetypes[i].Data {
+			if got := string(b.Archetypes[i].Data[name]); got != string(data) {
+				t.Errorf("component %q differs between runs:\n%s\n%s", name, data, got)
+			}
+		}
+	}
+}
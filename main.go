@@ -2,77 +2,231 @@ package main
 
 import (
 	"context"
+	"encoding/json"
+	"errors"
 	"flag"
+	"fmt"
+	"io"
 	"log/slog"
 	"os"
-	"time"
+	"os/signal"
+	"path/filepath"
+	"strings"
+	"syscall"
+	"text/tabwriter"
 
 	"github.com/donovandicks/llm-government/internal"
+	"gopkg.in/yaml.v3"
 )
 
-var (
-	TickDuration time.Duration = 16667 * time.Microsecond
-
-	sim      internal.Simulation = internal.Simulation{}
-	fromFile string              = ""
+// Exit codes of the commands.
+const (
+	exitOK          = 0
+	exitFailure     = 1   // The command failed, e.g. a file could not be read
+	exitInvalid     = 2   // Invalid arguments or simulation config
+	exitInterrupted = 130 // Stopped by SIGINT or SIGTERM, after shutting down gracefully
 )
 
-func parseArgs() {
-	flag.StringVar(&fromFile, "from-file", "", "Load the scenario settings from a JSON file")
-	flag.StringVar(&sim.Scenario, "scenario", "", "Describe the scenario the agents are participating in")
-	flag.Parse()
+type command struct {
+	name    string
+	summary string
+	run     func(ctx context.Context, args []string) int
+}
 
-	if fromFile != "" {
-		loaded, err := internal.LoadSimulationFromFile(fromFile)
-		if err != nil {
-			slog.Error("failed to load simulation config", "error", err)
-			os.Exit(1)
-		}
-		sim = *loaded
+var commands []command
+
+func init() {
+	commands = []command{
+		{"run", "Run a simulation", runCommand},
+		{"validate", "Check a simulation config, or print its JSON schema", validateCommand},
+		{"config", "Print the resolved simulation config", configCommand},
+		{"replay", "Render the transcript of a past run from its audit log", replayCommand},
+		{"inspect", "Print the world state of a snapshot or checkpoint", inspectCommand},
 	}
+}
 
-	if sim.Scenario == "" {
-		slog.Error("invalid simulation config: scenario cannot be empty")
-		os.Exit(1)
+func usage(w io.Writer) {
+	fmt.Fprintf(w, "Usage: %s <command> [flags]\n\nCommands:\n", filepath.Base(os.Args[0]))
+	tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+	for _, cmd := range commands {
+		fmt.Fprintf(tw, "  %s\t%s\n", cmd.name, cmd.summary)
 	}
+	tw.Flush()
+	fmt.Fprintf(w, "\nRun %s <command> -h for the flags of a command.\n", filepath.Base(os.Args[0]))
 }
 
 func main() {
-	ctx := context.Background()
-	shutdown, err := internal.SetupOTelSDK(ctx)
-	if err != nil {
-		slog.Error("failed to initialize otel sdk", "error", err)
-		os.Exit(1)
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	code := dispatch(ctx, os.Args[1:])
+	stop()
+	os.Exit(code)
+}
+
+func dispatch(ctx context.Context, args []string) int {
+	if len(args) == 0 {
+		usage(os.Stderr)
+		return exitInvalid
 	}
-	defer shutdown(ctx)
 
-	parseArgs()
-	slog.Debug("parsed simulation", "simulation", sim)
+	switch args[0] {
+	case "help", "-h", "-help", "--help":
+		usage(os.Stdout)
+		return exitOK
+	}
 
-	auditor := internal.NewAuditor()
-	defer auditor.Stop()
-	go auditor.Run()
+	for _, cmd := range commands {
+		if cmd.name == args[0] {
+			return cmd.run(ctx, args[1:])
+		}
+	}
 
-	world := internal.NewWorld().
-		RegisterOutput(new(internal.ApprovalMetric))
+	fmt.Fprintf(os.Stderr, "unknown command %q\n\n", args[0])
+	usage(os.Stderr)
+	return exitInvalid
+}
 
-	for range 5 {
-		world.RegisterEntity(internal.NewPersonEntity()...)
+// newFlagSet returns the flag set of a command, whose usage describes its
+// positional arguments.
+func newFlagSet(name, positional string) *flag.FlagSet {
+	fs := flag.NewFlagSet(name, flag.ContinueOnError)
+	fs.Usage = func() {
+		fmt.Fprintf(fs.Output(), "Usage: %s %s [flags] %s\n\nFlags:\n", filepath.Base(os.Args[0]), name, positional)
+		fs.PrintDefaults()
 	}
+	return fs
+}
 
-	bus := internal.NewInMemoryMessageBus(auditor.AuditLog)
+// parseFlags parses the arguments of a command, expecting nargs positional
+// arguments. If the command should not go on, it returns false along with
+// the exit code.
+func parseFlags(fs *flag.FlagSet, args []string, nargs int) (int, bool) {
+	if err := fs.Parse(args); err != nil {
+		if errors.Is(err, flag.ErrHelp) {
+			return exitOK, false
+		}
+		return exitInvalid, false
+	}
 
-	council := internal.NewCouncil(bus, world, internal.CouncilOptions{MaxRounds: 3}).
-		RegisterAgents(
-			internal.NewAgent(ctx, sim, bus),
-			internal.NewAgent(ctx, sim, bus),
-		)
+	if fs.NArg() != nargs {
+		fmt.Fprintf(fs.Output(), "%s expects %d arguments, got %d\n", fs.Name(), nargs, fs.NArg())
+		fs.Usage()
+		return exitInvalid, false
+	}
+	return exitOK, true
+}
 
-	go func() {
-		for {
-			world.Tick(ctx, TickDuration)
+// listFlag is a flag that can be repeated, collecting each value.
+type listFlag []string
+
+func (f *listFlag) String() string { return strings.Join(*f, ", ") }
+
+func (f *listFlag) Set(value string) error {
+	*f = append(*f, value)
+	return nil
+}
+
+// configFlags are the flags composing the simulation config, shared by the
+// commands loading it.
+type configFlags struct {
+	fs       *flag.FlagSet
+	files    listFlag
+	sets     listFlag
+	scenario string
+	seed     uint64
+}
+
+func addConfigFlags(fs *flag.FlagSet) *configFlags {
+	c := &configFlags{fs: fs}
+	fs.Var(&c.files, "from-file", "Load the simulation config from a YAML or JSON file. Repeat to merge each file over the previous ones")
+	fs.Var(&c.sets, "set", "Override a field of the simulation config, e.g. -set population.size=500. May be repeated")
+	fs.StringVar(&c.scenario, "scenario", "", "Describe the scenario the agents are participating in. Overrides the config's scenario")
+	fs.Uint64Var(&c.seed, "seed", 0, "Seed every random stream of the simulation, chosen randomly if 0. Overrides the config's seed")
+	return c
+}
+
+// load composes and validates the simulation config from the flags.
+func (c *configFlags) load() (*internal.Simulation, error) {
+	var overrides []internal.Override
+	for _, set := range c.sets {
+		override, err := internal.ParseOverride(set)
+		if err != nil {
+			return nil, err
 		}
-	}()
+		override.Source = "-set"
+		overrides = append(overrides, override)
+	}
+
+	c.fs.Visit(func(f *flag.Flag) {
+		switch f.Name {
+		case "scenario":
+			overrides = append(overrides, internal.Override{Path: []string{"scenario"}, Value: c.scenario, Source: "-scenario"})
+		case "seed":
+			overrides = append(overrides, internal.Override{Path: []string{"seed"}, Value: c.seed, Source: "-seed"})
+		}
+	})
+
+	return internal.LoadSimulation(c.files, overrides...)
+}
+
+// logInvalidConfig logs each of the problems found in the simulation
+// config.
+func logInvalidConfig(err error) {
+	for _, problem := range strings.Split(err.Error(), "\n") {
+		slog.Error("invalid simulation config", "error", problem)
+	}
+}
+
+func writeConfig(w io.Writer, sim *internal.Simulation) error {
+	encoder := yaml.NewEncoder(w)
+	encoder.SetIndent(2)
+	if err := encoder.Encode(sim); err != nil {
+		return err
+	}
+	return encoder.Close()
+}
+
+func validateCommand(ctx context.Context, args []string) int {
+	fs := newFlagSet("validate", "")
+	config := addConfigFlags(fs)
+	schema := fs.Bool("schema", false, "Print the JSON schema of the simulation config format instead, e.g. for editor completion")
+	if code, ok := parseFlags(fs, args, 0); !ok {
+		return code
+	}
 
-	council.Start(ctx)
+	if *schema {
+		out, _ := json.MarshalIndent(internal.SimulationSchema(), "", "  ")
+		fmt.Println(string(out))
+		return exitOK
+	}
+
+	if _, err := config.load(); err != nil {
+		// Problems are printed as is, so editors can jump to their location
+		for _, problem := range strings.Split(err.Error(), "\n") {
+			fmt.Fprintln(os.Stderr, problem)
+		}
+		return exitInvalid
+	}
+
+	fmt.Println("simulation config is valid")
+	return exitOK
+}
+
+func configCommand(ctx context.Context, args []string) int {
+	fs := newFlagSet("config", "")
+	config := addConfigFlags(fs)
+	if code, ok := parseFlags(fs, args, 0); !ok {
+		return code
+	}
+
+	sim, err := config.load()
+	if err != nil {
+		logInvalidConfig(err)
+		return exitInvalid
+	}
+
+	if err := writeConfig(os.Stdout, sim); err != nil {
+		slog.Error("failed to print simulation config", "error", err)
+		return exitFailure
+	}
+	return exitOK
 }
@@ -0,0 +1,113 @@
+package main
+
+import (
+	"bufio"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"log/slog"
+	"maps"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+
+	"github.com/donovandicks/llm-government/internal"
+)
+
+// renderMessage writes a message of the audit log along with the proposals
+// and votes it carries.
+func renderMessage(w io.Writer, msg internal.Message) {
+	fmt.Fprintf(w, "[%s] %s", msg.Metadata.SentAt, msg.Metadata.Sender)
+	if len(msg.Metadata.AddressedTo) > 0 {
+		fmt.Fprintf(w, " -> %s", strings.Join(msg.Metadata.AddressedTo, ", "))
+	}
+	fmt.Fprintln(w)
+
+	for line := range strings.Lines(strings.TrimSpace(msg.Contents)) {
+		fmt.Fprintf(w, "  %s", line)
+	}
+	fmt.Fprintln(w)
+
+	for _, p := range msg.Proposals {
+		fmt.Fprintf(w, "  + proposes %s %q: %s\n", p.ID, p.Title, p.Description)
+		for _, action := range p.Actions {
+			fmt.Fprintf(w, "      sets %s to %v\n", action.InputName, action.Value)
+		}
+	}
+	for _, v := range msg.Votes {
+		fmt.Fprintf(w, "  + votes %s on %s: %s\n", v.Choice, v.ProposalID, v.Reason)
+	}
+	fmt.Fprintln(w)
+}
+
+// renderProposals writes the outcome of every proposal of a checkpoint.
+func renderProposals(w io.Writer, proposals map[string]*internal.ProposalRecord) {
+	fmt.Fprintln(w, "Proposals:")
+	if len(proposals) == 0 {
+		fmt.Fprintln(w, "  none")
+	}
+
+	for _, id := range slices.Sorted(maps.Keys(proposals)) {
+		record := proposals[id]
+		votes := map[internal.VoteChoice]int{}
+		for _, vote := range record.Votes {
+			votes[vote.Choice]++
+		}
+		fmt.Fprintf(w, "  %s %q by %s: %s (%d yes, %d no, %d abstain)\n", id, record.Title, record.Author, record.Status,
+			votes[internal.VoteYes], votes[internal.VoteNo], votes[internal.VoteAbstain])
+	}
+}
+
+// replayCommand renders the transcript of a past run from its audit log,
+// followed by the outcome of its proposals.
+func replayCommand(ctx context.Context, args []string) int {
+	flags := newFlagSet("replay", "<run directory | audit log>")
+	checkpointFile := flags.String("checkpoint", "", "The checkpoint whose proposals are listed after the transcript, final.json of the run directory if unset")
+	if code, ok := parseFlags(flags, args, 1); !ok {
+		return code
+	}
+
+	auditLog := flags.Arg(0)
+	if info, err := os.Stat(auditLog); err == nil && info.IsDir() {
+		if *checkpointFile == "" {
+			// Runs that crashed have no final checkpoint
+			final := filepath.Join(auditLog, "final.json")
+			if _, err := os.Stat(final); !errors.Is(err, fs.ErrNotExist) {
+				*checkpointFile = final
+			}
+		}
+		auditLog = filepath.Join(auditLog, "audit.jsonl")
+	}
+
+	msgs, err := internal.ReadAuditLog(auditLog)
+	if err != nil {
+		slog.Error("failed to read audit log", "error", err)
+		return exitFailure
+	}
+
+	var cp *internal.Checkpoint
+	if *checkpointFile != "" {
+		if cp, err = internal.LoadCheckpointFromFile(*checkpointFile); err != nil {
+			slog.Error("failed to load checkpoint", "error", err)
+			return exitFailure
+		}
+	}
+
+	w := bufio.NewWriter(os.Stdout)
+	defer w.Flush()
+
+	for _, msg := range msgs {
+		if ctx.Err() != nil {
+			return exitInterrupted
+		}
+		renderMessage(w, msg)
+	}
+
+	if cp != nil {
+		renderProposals(w, cp.Proposals)
+	}
+	return exitOK
+}
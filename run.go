@@ -0,0 +1,421 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"math/rand/v2"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+
+	"github.com/donovandicks/llm-government/internal"
+)
+
+var (
+	sim        internal.Simulation = internal.Simulation{}
+	llm        string              = ""
+	scriptFile string              = ""
+	model      string              = ""
+	baseURL    string              = ""
+	recordFile string              = ""
+	replayFile string              = ""
+
+	checkpointFile  string = ""
+	checkpointEvery int64  = 0
+	restoreFile     string = ""
+
+	ticksPerObservation int64  = 0
+	maxTicks            int64  = 0
+	maxCycles           int    = 0
+	outDir              string = ""
+
+	agentCount int    = 2
+	forkAt     int64  = -1
+	forkFile   string = ""
+	forkFrom   string = ""
+
+	agentOpts internal.AgentOptions
+	recorder  *internal.CassetteRecorder
+	player    *internal.CassettePlayer
+)
+
+// checkRunFlags reports the first invalid combination of run flags.
+func checkRunFlags() error {
+	for _, cfg := range councilAgents() {
+		provider := agentProvider(cfg)
+		switch provider {
+		case "openai", "chat", "scripted":
+		default:
+			return fmt.Errorf("invalid llm config: unknown provider %q for agent %q", provider, cfg.Name)
+		}
+
+		if provider == "scripted" && scriptFile == "" {
+			return errors.New("invalid llm config: the scripted provider requires -llm-script")
+		}
+	}
+
+	if err := agentOpts.Memory.Validate(); err != nil {
+		return fmt.Errorf("invalid memory config: %w", err)
+	}
+
+	switch {
+	case recordFile != "" && replayFile != "":
+		return errors.New("invalid llm config: -record and -replay are mutually exclusive")
+	case agentCount <= 0:
+		return errors.New("invalid council config: -agents must be positive")
+	case restoreFile != "" && forkFrom != "":
+		return errors.New("invalid checkpoint config: -restore and -fork-from are mutually exclusive")
+	case maxTicks < 0 || maxCycles < 0:
+		return errors.New("invalid run config: -max-ticks and -max-cycles must not be negative")
+	}
+	return nil
+}
+
+// councilAgents returns the agents configured for the simulation, or
+// -agents anonymous agents if none are.
+func councilAgents() []internal.AgentConfig {
+	if len(sim.Agents) > 0 {
+		return sim.Agents
+	}
+	return make([]internal.AgentConfig, agentCount)
+}
+
+func agentProvider(cfg internal.AgentConfig) string {
+	if cfg.Provider != "" {
+		return cfg.Provider
+	}
+	return llm
+}
+
+// newLLMClient builds the client for the agent identified by key, which
+// is how agents are referred to in LLM scripts. The agent's config takes
+// precedence over the command line.
+func newLLMClient(key string, cfg internal.AgentConfig) (internal.LLMClient, error) {
+	if player != nil {
+		return player.Client(), nil
+	}
+
+	agentModel := model
+	if cfg.Model != "" {
+		agentModel = cfg.Model
+	}
+	agentBaseURL := baseURL
+	if cfg.BaseURL != "" {
+		agentBaseURL = cfg.BaseURL
+	}
+
+	var client internal.LLMClient
+	switch provider := agentProvider(cfg); provider {
+	case "openai":
+		client = internal.NewOpenAIResponsesClient(agentModel)
+	case "chat":
+		client = internal.NewOpenAIChatClient(agentModel, agentBaseURL)
+	case "scripted":
+		script, err := internal.LoadScriptFromFile(scriptFile)
+		if err != nil {
+			return nil, err
+		}
+		client = internal.NewScriptedClient(script, key)
+	default:
+		return nil, fmt.Errorf("unknown llm provider %q", provider)
+	}
+
+	if recorder != nil {
+		client = recorder.Wrap(client)
+	}
+
+	return client, nil
+}
+
+// writeRunConfig writes the resolved simulation config, including its
+// seed, to the output directory, so the run can be reproduced.
+func writeRunConfig() error {
+	f, err := os.Create(filepath.Join(outDir, "simulation.yaml"))
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	return writeConfig(f, &sim)
+}
+
+// runCommand runs the simulation until -max-ticks or -max-cycles is
+// reached, or until it is interrupted. The audit log, telemetry and the
+// final state of the simulation are flushed either way.
+func runCommand(ctx context.Context, args []string) (code int) {
+	fs := newFlagSet("run", "")
+	config := addConfigFlags(fs)
+	fs.Int64Var(&ticksPerObservation, "ticks-per-observation", 0, "Advance the world this many ticks before each council observation instead of ticking it concurrently, making runs reproducible. Overrides world.observeEvery")
+	fs.Int64Var(&maxTicks, "max-ticks", 0, "Stop the simulation once the world reaches this tick, unlimited if 0")
+	fs.IntVar(&maxCycles, "max-cycles", 0, "Stop the simulation after this many council cycles, each observing the world once, unlimited if 0")
+	fs.StringVar(&outDir, "out", "", "The directory the resolved config, audit log, checkpoints and final state of the run are written to, runs/<simulation id> if unset")
+	fs.StringVar(&llm, "llm", "openai", "The LLM provider used by agents without a provider in their config: openai, chat or scripted")
+	fs.StringVar(&model, "llm-model", "gpt-5", "The model used by agents without a model in their config")
+	fs.StringVar(&baseURL, "llm-base-url", "", "The base URL of an OpenAI-compatible chat completions server, for agents without a baseUrl in their config")
+	fs.StringVar(&scriptFile, "llm-script", "", "Load the replies of the scripted LLM provider from a YAML file")
+	fs.IntVar(&agentOpts.Memory.Window, "memory-window", 0, "The maximum number of history entries recalled by agents, unlimited if 0")
+	fs.IntVar(&agentOpts.Memory.TokenBudget, "memory-tokens", 0, "The maximum estimated tokens of history recalled by agents, unlimited if 0")
+	fs.IntVar(&agentOpts.Memory.SummarizeThreshold, "memory-summarize-tokens", 0, "The estimated tokens of stored history above which agents summarize older entries, disabled if 0")
+	fs.IntVar(&agentOpts.Memory.SummarizeKeep, "memory-summarize-keep", 10, "The number of most recent history entries agents never summarize")
+	fs.StringVar(&recordFile, "record", "", "Record every LLM request and response to a cassette file")
+	fs.StringVar(&replayFile, "replay", "", "Replay LLM responses from a cassette file instead of calling the provider")
+	fs.StringVar(&checkpointFile, "checkpoint", "", "The file periodic world checkpoints are written to, checkpoint.json in the output directory if unset")
+	fs.Int64Var(&checkpointEvery, "checkpoint-every", 0, "Checkpoint the world every n ticks, disabled if 0")
+	fs.StringVar(&restoreFile, "restore", "", "Restore the world from a checkpoint file instead of generating a new population")
+	fs.IntVar(&agentCount, "agents", 2, "The number of anonymous agents on the council when the simulation config has none")
+	fs.Int64Var(&forkAt, "fork-at", -1, "Write a checkpoint of the whole simulation once the council observes this tick, disabled if negative")
+	fs.StringVar(&forkFile, "fork-file", "", "The file the -fork-at checkpoint is written to, fork.json in the output directory if unset")
+	fs.StringVar(&forkFrom, "fork-from", "", "Continue a simulation from a checkpoint, e.g. with a different scenario, model or council")
+	if code, ok := parseFlags(fs, args, 0); !ok {
+		return code
+	}
+
+	// The config is loaded before telemetry is set up, so invalid configs
+	// are reported on stderr
+	loaded, err := config.load()
+	if err != nil {
+		logInvalidConfig(err)
+		return exitInvalid
+	}
+	sim = *loaded
+
+	if ticksPerObservation == 0 {
+		ticksPerObservation = sim.World.ObserveEvery
+	}
+
+	if sim.Seed == 0 {
+		sim.Seed = rand.Uint64()
+	}
+
+	if err := checkRunFlags(); err != nil {
+		slog.Error(err.Error())
+		return exitInvalid
+	}
+
+	if outDir == "" {
+		outDir = filepath.Join("runs", sim.ID())
+	}
+	if checkpointFile == "" {
+		checkpointFile = filepath.Join(outDir, "checkpoint.json")
+	}
+	if forkFile == "" {
+		forkFile = filepath.Join(outDir, "fork.json")
+	}
+
+	if err := os.MkdirAll(outDir, 0o755); err != nil {
+		slog.Error("failed to create output directory", "error", err)
+		return exitFailure
+	}
+	if err := writeRunConfig(); err != nil {
+		slog.Error("failed to write simulation config", "error", err)
+		return exitFailure
+	}
+
+	shutdown, err := internal.SetupOTelSDK(ctx)
+	if err != nil {
+		slog.Error("failed to initialize otel sdk", "error", err)
+		return exitFailure
+	}
+	defer func() {
+		// Telemetry is flushed even once the run has been interrupted
+		ctx, cancel := context.WithTimeout(context.WithoutCancel(ctx), 10*time.Second)
+		defer cancel()
+		if err := shutdown(ctx); err != nil {
+			slog.Error("failed to shut down otel sdk", "error", err)
+		}
+	}()
+
+	slog.Debug("parsed simulation", "simulation", sim)
+	slog.Info("seeded simulation", "seed", sim.Seed, "out", outDir)
+
+	rng := sim.RNG()
+	internal.SeedFaker(rng.Stream("faker"))
+
+	if recordFile != "" {
+		recorder, err = internal.NewCassetteRecorder(recordFile)
+		if err != nil {
+			slog.Error("failed to open cassette", "error", err)
+			return exitFailure
+		}
+		defer recorder.Close()
+	}
+
+	if replayFile != "" {
+		player, err = internal.LoadCassette(replayFile)
+		if err != nil {
+			slog.Error("failed to load cassette", "error", err)
+			return exitFailure
+		}
+	}
+
+	auditor, err := internal.NewAuditor(filepath.Join(outDir, "audit.jsonl"))
+	if err != nil {
+		slog.Error("failed to start auditor", "error", err)
+		return exitFailure
+	}
+	go auditor.Run()
+	defer auditor.Stop()
+
+	world := internal.NewWorld().
+		WithRNG(rng.Stream("world")).
+		RegisterOutput(new(internal.ApprovalMetric))
+
+	if err := sim.World.Configure(world); err != nil {
+		slog.Error("invalid world config", "error", err)
+		return exitInvalid
+	}
+	tickDuration, timePerTick := sim.World.Tick()
+
+	var fork *internal.Checkpoint
+	if forkFrom != "" {
+		fork, err = internal.LoadCheckpointFromFile(forkFrom)
+		if err != nil {
+			slog.Error("failed to load fork checkpoint", "error", err)
+			return exitFailure
+		}
+	} else if restoreFile != "" {
+		if err := world.LoadFromFile(restoreFile); err != nil {
+			slog.Error("failed to restore world", "error", err)
+			return exitFailure
+		}
+		slog.Info("restored world", "file", restoreFile, "tick", world.CurrentTick())
+	} else {
+		population := internal.PopulationConfig{}
+		if sim.Population != nil {
+			population = *sim.Population
+		}
+
+		people, err := internal.NewPersonGenerator(population, rng.Stream("population"))
+		if err != nil {
+			slog.Error("invalid population config", "error", err)
+			return exitInvalid
+		}
+		people.Populate(world)
+	}
+
+	bus := internal.NewInMemoryMessageBus(auditor.AuditLog)
+
+	// Streams of a fork are derived separately, so its message IDs never
+	// repeat those sent before the checkpoint
+	participants := rng
+	if fork != nil {
+		participants = rng.Stream(fmt.Sprintf("fork/%d", fork.World.Tick))
+	}
+
+	// The simulation stops early once -max-ticks is reached, as well as
+	// when the run is interrupted
+	simCtx, stopSim := context.WithCancel(ctx)
+	defer stopSim()
+
+	step := func(ctx context.Context) {
+		if maxTicks > 0 && world.CurrentTick() >= maxTicks {
+			stopSim()
+			return
+		}
+
+		world.Tick(ctx, timePerTick)
+
+		if checkpointEvery > 0 && world.CurrentTick()%checkpointEvery == 0 {
+			if err := world.SaveToFile(checkpointFile); err != nil {
+				slog.Error("failed to checkpoint world", "error", err)
+			}
+		}
+	}
+
+	councilOpts := internal.CouncilOptions{MaxRounds: 3, MaxCycles: maxCycles, RNG: participants.Stream("council")}
+	if ticksPerObservation > 0 {
+		councilOpts.Advance = func(ctx context.Context) {
+			for range ticksPerObservation {
+				step(ctx)
+			}
+		}
+	}
+	if forkAt >= 0 {
+		councilOpts.CheckpointAt = forkAt
+		councilOpts.OnCheckpoint = func(ctx context.Context, cp *internal.Checkpoint) {
+			if err := cp.SaveToFile(forkFile); err != nil {
+				slog.ErrorContext(ctx, "failed to write fork checkpoint", "error", err)
+				return
+			}
+			slog.InfoContext(ctx, "wrote fork checkpoint", "file", forkFile, "tick", cp.World.Tick)
+		}
+	}
+
+	council := internal.NewCouncil(bus, world, councilOpts)
+	for i, cfg := range councilAgents() {
+		key := cfg.Name
+		if key == "" {
+			key = fmt.Sprintf("agent-%d", i+1)
+		}
+
+		client, err := newLLMClient(key, cfg)
+		if err != nil {
+			slog.Error("failed to initialize llm client", "error", err)
+			return exitFailure
+		}
+
+		opts := agentOpts
+		opts.Config = cfg
+		opts.RNG = participants.Stream(fmt.Sprintf("agent/%d", i))
+		if fork != nil {
+			// Agents missing from the checkpoint start fresh with a new ID
+			if id, ok := fork.AgentID(cfg.Name, i); ok {
+				opts.ID = id
+			}
+		}
+		council.RegisterAgents(internal.NewAgent(ctx, sim, bus, client, opts))
+	}
+
+	if fork != nil {
+		if err := council.Restore(fork); err != nil {
+			slog.Error("failed to restore fork checkpoint", "error", err)
+			return exitFailure
+		}
+		slog.Info("forked simulation", "file", forkFrom, "tick", world.CurrentTick())
+	}
+
+	var ticking sync.WaitGroup
+	if ticksPerObservation <= 0 {
+		ticking.Go(func() {
+			ticker := time.NewTicker(tickDuration)
+			defer ticker.Stop()
+
+			for {
+				select {
+				case <-simCtx.Done():
+					return
+				case <-ticker.C:
+					step(simCtx)
+				}
+			}
+		})
+	}
+
+	// A diverged replay stops the run, which is still flushed
+	if err := council.Start(simCtx); err != nil {
+		slog.Error("simulation failed", "error", err)
+		code = exitFailure
+	}
+	stopSim()
+	ticking.Wait()
+
+	// The final state can be inspected, replayed or forked from
+	finalFile := filepath.Join(outDir, "final.json")
+	if cp, err := council.Checkpoint(); err != nil {
+		slog.Error("failed to checkpoint simulation", "error", err)
+		code = exitFailure
+	} else if err := cp.SaveToFile(finalFile); err != nil {
+		slog.Error("failed to write final checkpoint", "error", err)
+		code = exitFailure
+	}
+
+	if ctx.Err() != nil {
+		slog.Info("simulation interrupted", "tick", world.CurrentTick(), "out", outDir)
+		return exitInterrupted
+	}
+	slog.Info("simulation finished", "tick", world.CurrentTick(), "out", outDir)
+	return code
+}